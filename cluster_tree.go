@@ -0,0 +1,67 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kmeans
+
+// ClusterTreeNode is a node of a hierarchical clustering. The root holds all the vectors, and the children
+// of a node partition its vectors. The leaves are the clusters of the flat clustering.
+type ClusterTreeNode struct {
+	// Centroid is the centroid of the vectors of the node.
+	Centroid []float64
+	// Members are the indexes of the input vectors of the node.
+	Members []int
+	// Size is the number of vectors of the node.
+	Size int64
+	// SSE is the sum of squared distances of the vectors of the node to its centroid.
+	SSE float64
+	// Depth is 0 for the root, and the depth of the parent plus 1 for the other nodes.
+	Depth int
+	// SplitOrder is the step at which the node was split, starting at 0 for the root, or -1 for a leaf.
+	// The children of a node are always split after it.
+	SplitOrder int
+	// LeafID is the index of the cluster in the flat clustering, or -1 for an inner node.
+	LeafID int
+	// Children are the nodes the node was split into.
+	Children []*ClusterTreeNode
+}
+
+// IsLeaf returns true if the node was not split.
+func (n *ClusterTreeNode) IsLeaf() bool {
+	return len(n.Children) == 0
+}
+
+// Leaves returns the leaves of the tree rooted at n, from left to right.
+func (n *ClusterTreeNode) Leaves() []*ClusterTreeNode {
+	if n.IsLeaf() {
+		return []*ClusterTreeNode{n}
+	}
+	var leaves []*ClusterTreeNode
+	for _, child := range n.Children {
+		leaves = append(leaves, child.Leaves()...)
+	}
+	return leaves
+}
+
+// Cut returns the clusters obtained by undoing all the splits after the first k-1, from left to right.
+// For a binary tree it returns min(k, number of leaves) clusters, which allows choosing k after clustering.
+func (n *ClusterTreeNode) Cut(k int) []*ClusterTreeNode {
+	if n.IsLeaf() || n.SplitOrder >= k-1 {
+		return []*ClusterTreeNode{n}
+	}
+	var clusters []*ClusterTreeNode
+	for _, child := range n.Children {
+		clusters = append(clusters, child.Cut(k)...)
+	}
+	return clusters
+}
@@ -0,0 +1,167 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	moarray2 "github.com/arjunsk/kmeans/utils/moarray"
+	"github.com/arjunsk/kmeans/utils/moerr"
+	"gonum.org/v1/gonum/mat"
+	"math"
+)
+
+// Assigner maps vectors to their nearest centroid, using the same distance function (and normalization)
+// that was used for clustering. It is used for Vector Index Mapping Queries once the centroids are trained.
+// An Assigner is read-only after construction, so it is safe for concurrent use.
+type Assigner struct {
+	centroids []*mat.VecDense
+	distFn    kmeans.DistanceFunction
+	normalize bool
+	workers   int
+}
+
+// NewAssigner returns an Assigner for the given centroids.
+// If normalize is true, the input vectors are normalized before computing the distances, which is what
+// the clusterer does for spherical kmeans.
+func NewAssigner(centroids [][]float64, distanceType kmeans.DistanceType, normalize bool) (*Assigner, error) {
+	if len(centroids) == 0 || len(centroids[0]) == 0 {
+		return nil, moerr.NewInternalErrorNoCtx("centroids is empty")
+	}
+
+	gonumCentroids, err := moarray2.ToGonumVectors[float64](centroids...)
+	if err != nil {
+		return nil, err
+	}
+
+	distanceFunction, err := resolveDistanceFn(distanceType)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAssigner(gonumCentroids, distanceFunction, normalize, 0), nil
+}
+
+func newAssigner(centroids []*mat.VecDense, distFn kmeans.DistanceFunction, normalize bool, workers int) *Assigner {
+	return &Assigner{
+		centroids: centroids,
+		distFn:    distFn,
+		normalize: normalize,
+		workers:   workers,
+	}
+}
+
+// Assign returns the index of the nearest centroid for each of the input vectors.
+// The vectors are processed in parallel.
+func (a *Assigner) Assign(vectors [][]float64) ([]int, error) {
+	gonumVectors, err := a.toGonumVectors(vectors)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make([]int, len(gonumVectors))
+	parallelFor(len(gonumVectors), a.workers, func(start, end int) {
+		for x := start; x < end; x++ {
+			labels[x], _ = a.nearest(gonumVectors[x])
+		}
+	})
+	return labels, nil
+}
+
+// AssignTopN returns, for each of the input vectors, the indices of the n nearest centroids and the distances
+// to them, sorted by increasing distance. It is used for multi-probe IVF search and for soft-assigning border
+// vectors to multiple lists. If n is larger than the number of centroids, all the centroids are returned.
+func (a *Assigner) AssignTopN(vectors [][]float64, n int) (indices [][]int, distances [][]float64, err error) {
+	if n <= 0 {
+		return nil, nil, moerr.NewInternalErrorNoCtx("n is out of bounds (must be > 0)")
+	}
+	if n > len(a.centroids) {
+		n = len(a.centroids)
+	}
+
+	gonumVectors, err := a.toGonumVectors(vectors)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	indices = make([][]int, len(gonumVectors))
+	distances = make([][]float64, len(gonumVectors))
+	parallelFor(len(gonumVectors), a.workers, func(start, end int) {
+		for x := start; x < end; x++ {
+			indices[x], distances[x] = a.nearestN(gonumVectors[x], n)
+		}
+	})
+	return indices, distances, nil
+}
+
+// nearestN returns the indices of the n closest centroids to vec and the distances to them, sorted by
+// increasing distance. Ties are broken by the lower centroid index.
+func (a *Assigner) nearestN(vec *mat.VecDense, n int) ([]int, []float64) {
+	topIdx := make([]int, 0, n)
+	topDist := make([]float64, 0, n)
+	for c := range a.centroids {
+		dist := a.distFn(vec, a.centroids[c])
+		if len(topDist) == n && dist >= topDist[n-1] {
+			continue
+		}
+
+		// insertion into the sorted top-n buffer.
+		pos := len(topDist)
+		if pos < n {
+			topIdx = append(topIdx, 0)
+			topDist = append(topDist, 0)
+		} else {
+			pos = n - 1
+		}
+		for pos > 0 && topDist[pos-1] > dist {
+			topIdx[pos] = topIdx[pos-1]
+			topDist[pos] = topDist[pos-1]
+			pos--
+		}
+		topIdx[pos] = c
+		topDist[pos] = dist
+	}
+	return topIdx, topDist
+}
+
+// nearest returns the index of the closest centroid to vec and the distance to it.
+func (a *Assigner) nearest(vec *mat.VecDense) (int, float64) {
+	minDist := math.MaxFloat64
+	closestCenter := 0
+	for c := range a.centroids {
+		dist := a.distFn(vec, a.centroids[c])
+		if dist < minDist {
+			minDist = dist
+			closestCenter = c
+		}
+	}
+	return closestCenter, minDist
+}
+
+// toGonumVectors validates the input vectors against the centroid dimension and converts them
+// to (normalized, if required) gonum vectors.
+func (a *Assigner) toGonumVectors(vectors [][]float64) ([]*mat.VecDense, error) {
+	gonumVectors, err := moarray2.ToGonumVectors[float64](vectors...)
+	if err != nil {
+		return nil, err
+	}
+	if len(gonumVectors) > 0 && gonumVectors[0].Len() != a.centroids[0].Len() {
+		return nil, moerr.NewArrayInvalidOpNoCtx(a.centroids[0].Len(), gonumVectors[0].Len())
+	}
+
+	if a.normalize {
+		moarray2.NormalizeGonumVectors(gonumVectors)
+	}
+	return gonumVectors, nil
+}
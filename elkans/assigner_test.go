@@ -0,0 +1,271 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"reflect"
+	"testing"
+)
+
+func TestAssigner_Assign(t *testing.T) {
+	type args struct {
+		centroids [][]float64
+		distType  kmeans.DistanceType
+		normalize bool
+		vectors   [][]float64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []int
+		wantErr bool
+	}{
+		{
+			name: "Test 1 - L2Distance",
+			args: args{
+				centroids: [][]float64{
+					{1, 2, 3, 4},
+					{10, 20, 30, 40},
+				},
+				distType: kmeans.L2Distance,
+				vectors: [][]float64{
+					{1, 2, 4, 5},
+					{11, 23, 33, 47},
+					{10, 20, 30, 40},
+					{4, 8, 12, 16},
+				},
+			},
+			want: []int{0, 1, 1, 0},
+		},
+		{
+			name: "Test 2 - Spherical with normalization",
+			args: args{
+				centroids: [][]float64{
+					{1, 0},
+					{0, 1},
+				},
+				distType:  kmeans.InnerProduct,
+				normalize: true,
+				vectors: [][]float64{
+					{500, 1},
+					{1, 500},
+					{-1, 10},
+				},
+			},
+			want: []int{0, 1, 1},
+		},
+		{
+			name: "Test 3 - Empty input",
+			args: args{
+				centroids: [][]float64{
+					{1, 2},
+				},
+				distType: kmeans.L2Distance,
+				vectors:  [][]float64{},
+			},
+			want: []int{},
+		},
+		{
+			name: "Test 4 - Dimension mismatch",
+			args: args{
+				centroids: [][]float64{
+					{1, 2, 3, 4},
+					{10, 20, 30, 40},
+				},
+				distType: kmeans.L2Distance,
+				vectors: [][]float64{
+					{1, 2, 4},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := NewAssigner(tt.args.centroids, tt.args.distType, tt.args.normalize)
+			if err != nil {
+				t.Errorf("NewAssigner() error = %v", err)
+				return
+			}
+			got, err := a.Assign(tt.args.vectors)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Assign() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Assign() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssigner_AssignTopN(t *testing.T) {
+	type args struct {
+		vectors [][]float64
+		n       int
+	}
+	centroids := [][]float64{{0}, {10}, {20}, {30}}
+	tests := []struct {
+		name          string
+		args          args
+		wantIndices   [][]int
+		wantDistances [][]float64
+		wantErr       bool
+	}{
+		{
+			name: "Test 1 - Top 2",
+			args: args{
+				vectors: [][]float64{{12}, {29}},
+				n:       2,
+			},
+			wantIndices:   [][]int{{1, 2}, {3, 2}},
+			wantDistances: [][]float64{{2, 8}, {1, 9}},
+		},
+		{
+			name: "Test 2 - Ties are broken by lower index",
+			args: args{
+				vectors: [][]float64{{5}},
+				n:       3,
+			},
+			wantIndices:   [][]int{{0, 1, 2}},
+			wantDistances: [][]float64{{5, 5, 15}},
+		},
+		{
+			name: "Test 3 - n larger than centroid count",
+			args: args{
+				vectors: [][]float64{{26}},
+				n:       10,
+			},
+			wantIndices:   [][]int{{3, 2, 1, 0}},
+			wantDistances: [][]float64{{4, 6, 16, 26}},
+		},
+		{
+			name: "Test 4 - Invalid n",
+			args: args{
+				vectors: [][]float64{{26}},
+				n:       0,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := NewAssigner(centroids, kmeans.L2Distance, false)
+			if err != nil {
+				t.Errorf("NewAssigner() error = %v", err)
+				return
+			}
+			gotIndices, gotDistances, err := a.AssignTopN(tt.args.vectors, tt.args.n)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AssignTopN() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(gotIndices, tt.wantIndices) {
+				t.Errorf("AssignTopN() indices got = %v, want %v", gotIndices, tt.wantIndices)
+			}
+			if !assertx.InEpsilonF64Slices(tt.wantDistances, gotDistances) {
+				t.Errorf("AssignTopN() distances got = %v, want %v", gotDistances, tt.wantDistances)
+			}
+		})
+	}
+}
+
+func TestNewAssigner(t *testing.T) {
+	tests := []struct {
+		name      string
+		centroids [][]float64
+		distType  kmeans.DistanceType
+		wantErr   bool
+	}{
+		{
+			name:      "Test 1 - Empty centroids",
+			centroids: [][]float64{},
+			distType:  kmeans.L2Distance,
+			wantErr:   true,
+		},
+		{
+			name:      "Test 2 - Dimension mismatch",
+			centroids: [][]float64{{1, 2}, {1, 2, 3}},
+			distType:  kmeans.L2Distance,
+			wantErr:   true,
+		},
+		{
+			name:      "Test 3 - Invalid distance type",
+			centroids: [][]float64{{1, 2}, {1, 3}},
+			distType:  kmeans.DistanceType(10),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAssigner(tt.centroids, tt.distType, false); (err != nil) != tt.wantErr {
+				t.Errorf("NewAssigner() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestElkanClusterer_Predict(t *testing.T) {
+	vectorList := [][]float64{
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+	}
+	km, err := NewKMeans(vectorList, 2, 500, 0.01, kmeans.L2Distance, kmeans.KmeansPlusPlus, false)
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	ekm := km.(*ElkanClusterer)
+
+	if _, err = ekm.Predict(vectorList); err == nil {
+		t.Errorf("Predict() should throw error before Cluster()")
+	}
+
+	if _, err = ekm.Cluster(); err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+
+	got, err := ekm.Predict(vectorList)
+	if err != nil {
+		t.Fatalf("Predict() error = %v", err)
+	}
+	if want := ekm.Result().Labels; !reflect.DeepEqual(got, want) {
+		t.Errorf("Predict() got = %v, want %v", got, want)
+	}
+
+	gotTop, _, err := ekm.PredictTopN(vectorList, 1)
+	if err != nil {
+		t.Fatalf("PredictTopN() error = %v", err)
+	}
+	for i := range gotTop {
+		if len(gotTop[i]) != 1 || gotTop[i][0] != got[i] {
+			t.Errorf("PredictTopN() got = %v, want %v", gotTop[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,296 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"context"
+	"github.com/arjunsk/kmeans"
+	moarray2 "github.com/arjunsk/kmeans/utils/moarray"
+	"github.com/arjunsk/kmeans/utils/moerr"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"sort"
+)
+
+const (
+	// gmeansCriticalValue is the critical value of the Anderson-Darling test, with estimated mean and
+	// variance, for a significance level of 0.0001.
+	gmeansCriticalValue = 1.8692
+	// gmeansMinClusterSize is the smallest cluster the Anderson-Darling test is run on.
+	gmeansMinClusterSize = 8
+)
+
+// AutoKClusterer chooses the number of clusters between minK and maxK. It starts with minK clusters, and at
+// each round tries to split every cluster in two with a 2-means ElkanClusterer run on its vectors. The
+// splits accepted by the criterion (see WithAutoKCriterion) are applied, the strongest first if there are
+// more than maxK allows, and all the vectors are clustered again from the resulting centroids. It stops
+// when no split is accepted or maxK is reached.
+//   - kmeans.XMeans compares the Bayesian Information Criterion of the cluster and of its two halves.
+//   - kmeans.GMeans runs the Anderson-Darling normality test on the vectors projected on the line between
+//     the two halves, with a significance level of 0.0001.
+//
+// Both criteria model the clusters as gaussians, so they are meant for kmeans.L2Distance. The options are
+// passed to every run, except WithInitialCentroids which is not supported, WithAlgorithm and
+// WithYinyangGroups which do not apply to an ElkanClusterer, and WithRestarts which does not apply to the
+// runs started from the split centroids.
+//
+// Ref Paper: https://www.cs.cmu.edu/~dpelleg/download/xmeans.pdf (X-means)
+// Ref Paper: https://papers.nips.cc/paper/2526-learning-the-k-in-k-means.pdf (G-means)
+type AutoKClusterer struct {
+	elkanRuns
+	minK      int
+	maxK      int
+	criterion kmeans.AutoKCriterion
+
+	final *ElkanClusterer // the run of all the vectors with the chosen k.
+}
+
+var _ kmeans.Clusterer = new(AutoKClusterer)
+
+// NewAutoKClusterer returns an AutoKClusterer choosing between minK and maxK clusters for vectors.
+// The defaults of the runs can be overridden using the With* options.
+func NewAutoKClusterer(vectors [][]float64, minK, maxK int, opts ...Option) (*AutoKClusterer, error) {
+	runs, cfg, err := newElkanRuns("auto k", vectors, minK, opts)
+	if err != nil {
+		return nil, err
+	}
+	if maxK < minK || maxK > len(vectors) {
+		return nil, moerr.NewInternalErrorNoCtx("max cluster count is out of bounds (must be >= %d and <= %d)",
+			minK, len(vectors))
+	}
+
+	return &AutoKClusterer{
+		elkanRuns: runs,
+		minK:      minK,
+		maxK:      maxK,
+		criterion: cfg.autoKCriterion,
+	}, nil
+}
+
+// InitCentroids does nothing: the centroids are initialized by the first run, and then by the splits.
+func (km *AutoKClusterer) InitCentroids() error {
+	return nil
+}
+
+// Cluster returns the centroids of the chosen k.
+func (km *AutoKClusterer) Cluster() ([][]float64, error) {
+	return km.ClusterContext(context.Background())
+}
+
+// ClusterContext is like Cluster, but stops when ctx is done and returns its error.
+func (km *AutoKClusterer) ClusterContext(ctx context.Context) ([][]float64, error) {
+	km.final = nil
+
+	current, err := km.newRun(km.vectors, km.minK, km.seed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = current.ClusterContext(ctx); err != nil {
+		return nil, err
+	}
+
+	for round := 1; current.clusterCnt < km.maxK; round++ {
+		result := current.Result()
+		members := make([][]int, len(result.Centroids))
+		for x, c := range result.Labels {
+			members[c] = append(members[c], x)
+		}
+
+		// the clusters are tested in order, with a different seed each, so the rounds are deterministic.
+		type split struct {
+			cluster  int
+			score    float64
+			children [][]float64
+		}
+		var splits []split
+		for c := range members {
+			seed := km.seed + int64(round*km.maxK+c)
+			ok, score, children, err := km.testSplit(ctx, members[c], result.Centroids[c], seed)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				splits = append(splits, split{cluster: c, score: score, children: children})
+			}
+		}
+		if len(splits) == 0 {
+			break
+		}
+
+		sort.SliceStable(splits, func(i, j int) bool {
+			return splits[i].score > splits[j].score
+		})
+		if free := km.maxK - current.clusterCnt; len(splits) > free {
+			splits = splits[:free]
+		}
+		splitChildren := make(map[int][][]float64, len(splits))
+		for _, s := range splits {
+			splitChildren[s.cluster] = s.children
+		}
+
+		var centroids [][]float64
+		for c, centroid := range result.Centroids {
+			if children, ok := splitChildren[c]; ok {
+				centroids = append(centroids, children...)
+			} else {
+				centroids = append(centroids, centroid)
+			}
+		}
+
+		current, err = km.newRun(km.vectors, len(centroids), km.seed, WithInitialCentroids(centroids), WithRestarts(1))
+		if err != nil {
+			return nil, err
+		}
+		if _, err = current.ClusterContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	km.final = current
+	return current.Result().Centroids, nil
+}
+
+// testSplit clusters the members of a cluster with 2-means, and returns whether the criterion accepts the
+// split, how strongly, and the centroids of the two halves.
+func (km *AutoKClusterer) testSplit(ctx context.Context, members []int, centroid []float64, seed int64) (
+	ok bool, score float64, children [][]float64, err error) {
+	if len(members) < 3 || (km.criterion == kmeans.GMeans && len(members) < gmeansMinClusterSize) {
+		return false, 0, nil, nil
+	}
+
+	twoMeans, err := km.newRun(km.subset(members), 2, seed)
+	if err != nil {
+		return false, 0, nil, err
+	}
+	if _, err = twoMeans.ClusterContext(ctx); err != nil {
+		return false, 0, nil, err
+	}
+	result := twoMeans.Result()
+	if result.ClusterSizes[0] == 0 || result.ClusterSizes[1] == 0 {
+		return false, 0, nil, nil
+	}
+
+	switch km.criterion {
+	case kmeans.GMeans:
+		score = km.andersonDarling(members, result.Centroids)
+		ok = score > gmeansCriticalValue
+	default:
+		parentSSE := 0.0
+		parentCentroid := moarray2.ToGonumVector[float64](centroid)
+		for _, x := range members {
+			parentSSE += math.Pow(km.distFn(km.vectorList[x], parentCentroid), 2)
+		}
+		dims := km.vectorList[0].Len()
+		parentBIC := bic([]int64{int64(len(members))}, parentSSE, dims)
+		childrenBIC := bic(result.ClusterSizes, result.SSE, dims)
+		score = childrenBIC - parentBIC
+		ok = score > 0
+	}
+	return ok, score, result.Centroids, nil
+}
+
+// bic returns the Bayesian Information Criterion of a clustering with the given cluster sizes and SSE, in
+// dims dimensions, modelling the clusters as spherical gaussians of the same variance.
+func bic(sizes []int64, sse float64, dims int) float64 {
+	var n int64
+	for _, size := range sizes {
+		n += size
+	}
+	k := int64(len(sizes))
+	if n <= k {
+		return math.Inf(-1)
+	}
+	if sse == 0 {
+		// all the vectors lie on their centroid: the likelihood is unbounded.
+		return math.Inf(1)
+	}
+
+	// maximum likelihood estimate of the variance, per dimension.
+	variance := sse / float64(int64(dims)*(n-k))
+	logLikelihood := -float64(n) * float64(dims) / 2 * math.Log(2*math.Pi*variance)
+	logLikelihood -= float64(int64(dims)*(n-k)) / 2
+	for _, size := range sizes {
+		logLikelihood += float64(size) * math.Log(float64(size)/float64(n))
+	}
+
+	// k-1 mixing weights, k*dims centroid coordinates and the variance.
+	params := float64(k-1) + float64(k)*float64(dims) + 1
+	return logLikelihood - params/2*math.Log(float64(n))
+}
+
+// andersonDarling projects the members on the line between the two centroids, and returns the
+// Anderson-Darling statistic of the standardized projections, corrected for the estimated mean and variance.
+func (km *AutoKClusterer) andersonDarling(members []int, centroids [][]float64) float64 {
+	direction := moarray2.ToGonumVector[float64](centroids[0])
+	direction.SubVec(direction, moarray2.ToGonumVector[float64](centroids[1]))
+
+	n := len(members)
+	projections := make([]float64, n)
+	var mean float64
+	for i, x := range members {
+		projections[i] = mat.Dot(km.vectorList[x], direction)
+		mean += projections[i]
+	}
+	mean /= float64(n)
+	var variance float64
+	for _, p := range projections {
+		variance += (p - mean) * (p - mean)
+	}
+	variance /= float64(n - 1)
+	if variance == 0 {
+		return 0
+	}
+
+	stdDev := math.Sqrt(variance)
+	for i := range projections {
+		projections[i] = (projections[i] - mean) / stdDev
+	}
+	sort.Float64s(projections)
+
+	// the normal CDF is clamped away from 0 and 1, so that the logs stay finite for outliers.
+	cdf := func(z float64) float64 {
+		return math.Min(math.Max(0.5*math.Erfc(-z/math.Sqrt2), 1e-15), 1-1e-15)
+	}
+	var sum float64
+	for i := 0; i < n; i++ {
+		sum += float64(2*i+1) * (math.Log(cdf(projections[i])) + math.Log(1-cdf(projections[n-1-i])))
+	}
+	a2 := -float64(n) - sum/float64(n)
+	return a2 * (1 + 4/float64(n) - 25/float64(n*n))
+}
+
+// K returns the chosen number of clusters, or 0 before Cluster.
+func (km *AutoKClusterer) K() int {
+	if km.final == nil {
+		return 0
+	}
+	return km.final.clusterCnt
+}
+
+// SSE returns the sum of squared errors of the chosen clustering.
+func (km *AutoKClusterer) SSE() float64 {
+	if km.final == nil {
+		return 0
+	}
+	return km.final.SSE()
+}
+
+// Result returns the clustering of the chosen k, or nil before Cluster. It is the result of the last run.
+func (km *AutoKClusterer) Result() *kmeans.ClusterResult {
+	if km.final == nil {
+		return nil
+	}
+	return km.final.Result()
+}
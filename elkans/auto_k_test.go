@@ -0,0 +1,144 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	"testing"
+)
+
+func TestAutoKClusterer_Cluster(t *testing.T) {
+	tests := []struct {
+		name      string
+		vectors   [][]float64
+		minK      int
+		maxK      int
+		criterion kmeans.AutoKCriterion
+		wantK     int
+	}{
+		{
+			name:      "Test 1 - X-means finds the blobs",
+			vectors:   blobs(600, 5, 4, 1),
+			minK:      1,
+			maxK:      20,
+			criterion: kmeans.XMeans,
+			wantK:     5,
+		},
+		{
+			name:      "Test 2 - G-means finds the blobs",
+			vectors:   blobs(600, 5, 4, 1),
+			minK:      1,
+			maxK:      20,
+			criterion: kmeans.GMeans,
+			wantK:     5,
+		},
+		{
+			name:      "Test 3 - X-means keeps a single gaussian",
+			vectors:   blobs(500, 1, 4, 2),
+			minK:      1,
+			maxK:      10,
+			criterion: kmeans.XMeans,
+			wantK:     1,
+		},
+		{
+			name:      "Test 4 - G-means keeps a single gaussian",
+			vectors:   blobs(500, 1, 4, 2),
+			minK:      1,
+			maxK:      10,
+			criterion: kmeans.GMeans,
+			wantK:     1,
+		},
+		{
+			name:      "Test 5 - capped at maxK",
+			vectors:   blobs(600, 5, 4, 1),
+			minK:      1,
+			maxK:      3,
+			criterion: kmeans.XMeans,
+			wantK:     3,
+		},
+		{
+			name:      "Test 6 - at least minK",
+			vectors:   blobs(500, 1, 4, 2),
+			minK:      4,
+			maxK:      10,
+			criterion: kmeans.GMeans,
+			wantK:     4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, err := NewAutoKClusterer(tt.vectors, tt.minK, tt.maxK, WithAutoKCriterion(tt.criterion))
+			if err != nil {
+				t.Fatalf("Error while creating KMeans object %v", err)
+			}
+			if km.K() != 0 || km.Result() != nil {
+				t.Errorf("K() and Result() before Cluster(), want 0 and nil")
+			}
+			centroids, err := km.Cluster()
+			if err != nil {
+				t.Fatalf("Cluster() error = %v", err)
+			}
+			if km.K() != tt.wantK || len(centroids) != tt.wantK {
+				t.Fatalf("K() got = %v with %d centroids, want %v", km.K(), len(centroids), tt.wantK)
+			}
+			if km.Result().SSE != km.SSE() {
+				t.Errorf("Result().SSE got = %v, want %v", km.Result().SSE, km.SSE())
+			}
+
+			// when k is the number of blobs, each cluster is a blob.
+			if tt.wantK == 5 {
+				labels := km.Result().Labels
+				for x, label := range labels {
+					if label != labels[x%5] {
+						t.Fatalf("Result().Labels got vectors %d and %d of the same blob in clusters %d and %d",
+							x%5, x, labels[x%5], label)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNewAutoKClusterer(t *testing.T) {
+	vectorList := [][]float64{{1}, {2}, {10}, {11}}
+	tests := []struct {
+		name       string
+		minK, maxK int
+		opts       []Option
+	}{
+		{name: "Test 1 - max below min", minK: 2, maxK: 1},
+		{name: "Test 2 - max above the vector count", minK: 1, maxK: 5},
+		{name: "Test 3 - initial centroids", minK: 1, maxK: 2, opts: []Option{WithInitialCentroids([][]float64{{1}})}},
+		{name: "Test 4 - invalid criterion", minK: 1, maxK: 2, opts: []Option{WithAutoKCriterion(100)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAutoKClusterer(vectorList, tt.minK, tt.maxK, tt.opts...); err == nil {
+				t.Errorf("NewAutoKClusterer() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestNewAutoKClusterer_YinyangGroups(t *testing.T) {
+	// the 2-means runs are ElkanClusterer runs, so the yinyang groups do not apply to them.
+	km, err := NewAutoKClusterer(blobs(400, 6, 2, 1), 4, 20, WithAlgorithm(kmeans.Yinyang), WithYinyangGroups(3))
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	if _, err = km.Cluster(); err != nil {
+		t.Errorf("Cluster() error = %v", err)
+	}
+}
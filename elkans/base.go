@@ -0,0 +1,571 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"context"
+	"github.com/arjunsk/kmeans"
+	moarray2 "github.com/arjunsk/kmeans/utils/moarray"
+	"github.com/arjunsk/kmeans/utils/moerr"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ctxCheckInterval is the number of vectors processed between two context cancellation checks.
+const ctxCheckInterval = 1024
+
+// clusterer holds the configuration and the state shared by the kmeans algorithms of this package, and
+// implements what does not depend on the algorithm: the centroid initialization, the centroid update with
+// the empty cluster policy, the convergence check, the restarts and the results.
+// An algorithm embeds it and provides the assignment step through the algorithm interface.
+// The assignment step runs the assignVector method of the algorithm over chunks of vectors in parallel:
+// assignVector only updates the state of the vector it is given, so different vectors can be assigned
+// concurrently.
+type clusterer struct {
+	// algo is the algorithm embedding this clusterer.
+	algo algorithm
+
+	// for each of the n vectors, we keep track of the following data
+	vectorList  []*mat.VecDense
+	assignments []int
+
+	// for each of the k centroids, we keep track of the following data
+	centroids []*mat.VecDense
+
+	// thresholds
+	maxIterations   int     // e in paper
+	deltaThreshold  float64 // used for early convergence.
+	convergenceType kmeans.ConvergenceType
+	prevSSE         float64 // used by SSEImprovement convergence.
+
+	// counts
+	clusterCnt int // k in paper
+	vectorCnt  int // n in paper
+
+	distFn             kmeans.DistanceFunction
+	initType           kmeans.InitType
+	initRounds         int                // used by kmeans.KmeansParallel
+	initOversampling   float64            // used by kmeans.KmeansParallel
+	initialCentroids   []*mat.VecDense    // if set, used instead of the initializer
+	initializerFactory InitializerFactory // if set, used instead of initType
+	seed               int64
+	randSource         rand.Source // user provided source; if nil, the source is seeded with seed.
+	rand               *rand.Rand
+	normalize          bool
+	workers            int
+	emptyClusterPolicy kmeans.EmptyClusterPolicy
+
+	// restarts is the number of runs with different seeds, out of which the lowest SSE run is kept.
+	restarts           int
+	concurrentRestarts bool
+
+	// run details, reported by Result()
+	iterations          int
+	convergenceReason   kmeans.ConvergenceReason
+	emptyClusterReseeds int
+
+	// run statistics, reported by Stats()
+	collectStats  bool
+	stats         *kmeans.RunStats
+	phases        kmeans.PhaseDurations
+	statsDistFn   kmeans.DistanceFunction // the distance function without the call counter.
+	statsDistMark int64                   // distance computations before the current iteration.
+}
+
+// algorithm is the part of a clusterer that is specific to a kmeans algorithm.
+type algorithm interface {
+	// iterate runs the iterations of the algorithm, starting from the centroids set by InitCentroids,
+	// until the clusterer reports convergence.
+	iterate(ctx context.Context) error
+	// newRun returns a copy of the algorithm for one of the restarts, with its own clustering state.
+	newRun(seed int64) algorithm
+	// base returns the clusterer embedded in the algorithm.
+	base() *clusterer
+}
+
+// NewClusterer returns a clusterer for clustering vectors into clusterCnt clusters, using the algorithm
+// set with WithAlgorithm. The defaults can be overridden using the With* options.
+func NewClusterer(vectors [][]float64, clusterCnt int, opts ...Option) (kmeans.Clusterer, error) {
+	cfg := newOptions(opts)
+	var km kmeans.Clusterer
+	var err error
+	switch cfg.algorithm {
+	case kmeans.Hamerly:
+		km, err = NewHamerlyClusterer(vectors, clusterCnt, opts...)
+	case kmeans.Yinyang:
+		km, err = NewYinyangClusterer(vectors, clusterCnt, opts...)
+	case kmeans.Lloyd:
+		km, err = NewLloydClusterer(vectors, clusterCnt, opts...)
+	case kmeans.MiniBatch:
+		km, err = NewMiniBatchClusterer(vectors, clusterCnt, opts...)
+	default:
+		km, err = NewElkanClusterer(vectors, clusterCnt, opts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return km, nil
+}
+
+// newClusterer validates the options and returns the shared state of a clusterer. The caller sets algo.
+func newClusterer(vectors [][]float64, clusterCnt int, cfg options) (clusterer, error) {
+	err := validateArgs(vectors, clusterCnt, cfg)
+	if err != nil {
+		return clusterer{}, err
+	}
+
+	gonumVectors, err := moarray2.ToGonumVectors[float64](vectors...)
+	if err != nil {
+		return clusterer{}, err
+	}
+	if cfg.normalize {
+		moarray2.NormalizeGonumVectors(gonumVectors)
+	}
+
+	var initialCentroids []*mat.VecDense
+	if cfg.initialCentroids != nil {
+		initialCentroids, err = moarray2.ToGonumVectors[float64](cfg.initialCentroids...)
+		if err != nil {
+			return clusterer{}, err
+		}
+		if cfg.normalize {
+			moarray2.NormalizeGonumVectors(initialCentroids)
+		}
+	}
+
+	distanceFunction, err := resolveDistanceFn(cfg.distanceType)
+	if err != nil {
+		return clusterer{}, err
+	}
+
+	randSource := cfg.randSource
+	if randSource == nil {
+		randSource = rand.NewSource(cfg.seed)
+	}
+
+	return clusterer{
+		maxIterations:   cfg.maxIterations,
+		deltaThreshold:  cfg.deltaThreshold,
+		convergenceType: cfg.convergenceType,
+
+		vectorList:  gonumVectors,
+		assignments: make([]int, len(vectors)),
+		//centroids will be initialized by InitCentroids()
+
+		distFn:     distanceFunction,
+		initType:   cfg.initType,
+		clusterCnt: clusterCnt,
+		vectorCnt:  len(vectors),
+
+		initRounds:         cfg.initRounds,
+		initOversampling:   cfg.initOversampling,
+		initialCentroids:   initialCentroids,
+		initializerFactory: cfg.initializerFactory,
+		seed:               cfg.seed,
+		randSource:         cfg.randSource,
+		rand:               rand.New(randSource),
+		normalize:          cfg.normalize,
+		workers:            cfg.workers,
+		emptyClusterPolicy: cfg.emptyClusterPolicy,
+		restarts:           cfg.restarts,
+		concurrentRestarts: cfg.concurrentRestarts,
+		collectStats:       cfg.stats,
+	}, nil
+}
+
+// newRun returns a single-run copy of km, seeded with seed and with its own assignments and centroids.
+// The input vectors are shared, as they are read-only.
+func (km *clusterer) newRun(seed int64) clusterer {
+	run := *km
+	run.algo = nil
+	run.assignments = make([]int, km.vectorCnt)
+	run.centroids = nil
+	run.seed = seed
+	run.randSource = nil
+	run.rand = rand.New(rand.NewSource(seed))
+	run.restarts = 1
+	return run
+}
+
+// InitCentroids initializes the centroids using initialization algorithms like random or kmeans++, or the
+// custom initializer set with WithInitializer or WithInitializerFactory.
+// The initializer draws from the random source of the clusterer.
+// If initial centroids were provided, they are used instead.
+func (km *clusterer) InitCentroids() error {
+	if km.initialCentroids != nil {
+		km.centroids = make([]*mat.VecDense, km.clusterCnt)
+		for c := range km.centroids {
+			km.centroids[c] = mat.VecDenseCopyOf(km.initialCentroids[c])
+		}
+		return nil
+	}
+
+	initializer, err := km.newInitializer()
+	if err != nil {
+		return err
+	}
+	centroids := initializer.InitCentroids(km.vectorList, km.clusterCnt)
+
+	// a custom initializer is not trusted to return well-formed centroids.
+	if len(centroids) != km.clusterCnt {
+		return moerr.NewInternalErrorNoCtx("initializer returned %d centroids, expected %d",
+			len(centroids), km.clusterCnt)
+	}
+	for _, centroid := range centroids {
+		if centroid == nil {
+			return moerr.NewInternalErrorNoCtx("initializer returned a nil centroid")
+		}
+		if centroid.Len() != km.vectorList[0].Len() {
+			return moerr.NewArrayInvalidOpNoCtx(km.vectorList[0].Len(), centroid.Len())
+		}
+	}
+	km.centroids = centroids
+	return nil
+}
+
+// Cluster returns the final centroids and the error if any.
+func (km *clusterer) Cluster() ([][]float64, error) {
+	return km.ClusterContext(context.Background())
+}
+
+// ClusterContext is like Cluster, but stops when ctx is done. The cancellation is checked between iterations
+// and periodically inside the assignment loop. On cancellation, it returns the latest centroids (nil if the
+// centroids are not yet initialized) along with ctx.Err(), and Result() reports kmeans.Cancelled.
+func (km *clusterer) ClusterContext(ctx context.Context) ([][]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if km.vectorCnt == km.clusterCnt {
+		// every vector is its own centroid.
+		km.centroids = km.vectorList
+		for x := range km.assignments {
+			km.assignments[x] = x
+		}
+		km.iterations = 0
+		km.convergenceReason = kmeans.NoReassignments
+		km.emptyClusterReseeds = 0
+		return moarray2.ToMoArrays[float64](km.vectorList), nil
+	}
+
+	var err error
+	if km.restarts > 1 {
+		err = km.clusterRestarts(ctx)
+	} else {
+		err = km.clusterOnce(ctx)
+	}
+	if err != nil {
+		if km.convergenceReason != kmeans.Cancelled || km.centroids == nil {
+			return nil, err
+		}
+		return moarray2.ToMoArrays[float64](km.centroids), err
+	}
+
+	return moarray2.ToMoArrays[float64](km.centroids), nil
+}
+
+// clusterOnce runs the algorithm once, starting from freshly initialized centroids.
+func (km *clusterer) clusterOnce(ctx context.Context) error {
+	km.iterations = 0
+	km.convergenceReason = kmeans.NotConverged
+	km.emptyClusterReseeds = 0
+
+	// restart the random sequence, so that every run with the same seed gives the same result.
+	if km.randSource == nil {
+		km.rand.Seed(km.seed)
+	}
+
+	km.startStats()
+	defer km.finishStats()
+
+	start := time.Now()
+	err := km.InitCentroids() // step 0.1
+	if err != nil {
+		return err
+	}
+	km.phases.Init += lap(&start)
+
+	if err = km.algo.iterate(ctx); err != nil {
+		km.convergenceReason = kmeans.Cancelled
+		return err
+	}
+	return nil
+}
+
+func validateArgs(vectorList [][]float64, clusterCnt int, cfg options) error {
+	if len(vectorList) == 0 || len(vectorList[0]) == 0 {
+		return moerr.NewInternalErrorNoCtx("input vectors is empty")
+	}
+	if err := validateOptions(clusterCnt, cfg); err != nil {
+		return err
+	}
+	if clusterCnt > len(vectorList) {
+		return moerr.NewInternalErrorNoCtx("cluster count is larger than vector count %d > %d", clusterCnt, len(vectorList))
+	}
+	if cfg.initialCentroids != nil {
+		for _, centroid := range cfg.initialCentroids {
+			if len(centroid) != len(vectorList[0]) {
+				return moerr.NewArrayInvalidOpNoCtx(len(vectorList[0]), len(centroid))
+			}
+		}
+	}
+
+	// We need to validate that all vectors have the same dimension.
+	// This is already done by moarray.ToGonumVectors, so skipping it here.
+
+	return nil
+}
+
+// validateOptions validates the cluster count and the options, for the clusterers that do not get all
+// their vectors upfront.
+func validateOptions(clusterCnt int, cfg options) error {
+	if clusterCnt <= 0 {
+		return moerr.NewInternalErrorNoCtx("cluster count is out of bounds (must be > 0)")
+	}
+	if cfg.algorithm > kmeans.MiniBatch {
+		return moerr.NewInternalErrorNoCtx("algorithm is not supported")
+	}
+	if cfg.maxIterations < 0 {
+		return moerr.NewInternalErrorNoCtx("max iteration is out of bounds (must be >= 0)")
+	}
+	if cfg.convergenceType > kmeans.SSEImprovement {
+		return moerr.NewInternalErrorNoCtx("convergence type is not supported")
+	}
+	// the centroid shift threshold is a distance, the other thresholds are ratios.
+	if cfg.convergenceType == kmeans.CentroidShift {
+		if cfg.deltaThreshold <= 0.0 {
+			return moerr.NewInternalErrorNoCtx("delta threshold is out of bounds (must be > 0.0)")
+		}
+	} else if cfg.deltaThreshold <= 0.0 || cfg.deltaThreshold >= 1.0 {
+		return moerr.NewInternalErrorNoCtx("delta threshold is out of bounds (must be > 0.0 and < 1.0)")
+	}
+	if cfg.distanceType > 2 {
+		return moerr.NewInternalErrorNoCtx("distance type is not supported")
+	}
+	if cfg.initializerFactory == nil && cfg.initType > kmeans.PCASplit {
+		return moerr.NewInternalErrorNoCtx("init type is not supported")
+	}
+	if cfg.initRounds < 1 {
+		return moerr.NewInternalErrorNoCtx("init rounds is out of bounds (must be >= 1)")
+	}
+	if cfg.initOversampling <= 0 {
+		return moerr.NewInternalErrorNoCtx("init oversampling factor is out of bounds (must be > 0)")
+	}
+	if cfg.workers < 0 {
+		return moerr.NewInternalErrorNoCtx("workers is out of bounds (must be >= 0)")
+	}
+	if cfg.emptyClusterPolicy > kmeans.EmptyClusterKeepPrevious {
+		return moerr.NewInternalErrorNoCtx("empty cluster policy is not supported")
+	}
+	if cfg.restarts < 1 {
+		return moerr.NewInternalErrorNoCtx("restarts is out of bounds (must be >= 1)")
+	}
+	if cfg.yinyangGroups < 0 || cfg.yinyangGroups > clusterCnt {
+		return moerr.NewInternalErrorNoCtx("yinyang groups is out of bounds (must be >= 0 and <= cluster count)")
+	}
+	if cfg.batchSize < 1 {
+		return moerr.NewInternalErrorNoCtx("batch size is out of bounds (must be >= 1)")
+	}
+	if cfg.maxNoImprovement < 0 {
+		return moerr.NewInternalErrorNoCtx("max no improvement is out of bounds (must be >= 0)")
+	}
+	if cfg.autoKCriterion > kmeans.GMeans {
+		return moerr.NewInternalErrorNoCtx("auto k criterion is not supported")
+	}
+	if cfg.initialCentroids != nil {
+		if len(cfg.initialCentroids) != clusterCnt {
+			return moerr.NewInternalErrorNoCtx("initial centroids count does not match cluster count %d != %d",
+				len(cfg.initialCentroids), clusterCnt)
+		}
+		if cfg.restarts > 1 {
+			return moerr.NewInternalErrorNoCtx("restarts are not supported with initial centroids")
+		}
+	}
+	if (clusterCnt * clusterCnt) > math.MaxInt {
+		return moerr.NewInternalErrorNoCtx("cluster count is too large for int*int")
+	}
+
+	return nil
+}
+
+// recalculateCentroids calculates the new mean centroids based on the new assignments.
+func (km *clusterer) recalculateCentroids() []*mat.VecDense {
+	membersCount := make([]int64, km.clusterCnt)
+
+	newCentroids := make([]*mat.VecDense, km.clusterCnt)
+	for c := range newCentroids {
+		newCentroids[c] = mat.NewVecDense(km.vectorList[0].Len(), nil)
+	}
+
+	// sum of all the members of the cluster
+	for x, vec := range km.vectorList {
+		cx := km.assignments[x]
+		membersCount[cx]++
+		newCentroids[cx].AddVec(newCentroids[cx], vec)
+	}
+
+	// means of the clusters = sum of all the members of the cluster / number of members in the cluster
+	// note: we don't need to normalize here, since the vectors are already normalized
+	for c := range newCentroids {
+		if membersCount[c] > 0 {
+			newCentroids[c].ScaleVec(1.0/float64(membersCount[c]), newCentroids[c])
+		}
+	}
+
+	// the mean of an empty set is undefined, so the empty clusters get a new centroid.
+	km.reseedEmptyClusters(newCentroids, membersCount)
+
+	return newCentroids
+}
+
+// centroidShifts returns the distance moved by each centroid, d(c, m(c)) in the paper, and the largest one.
+func (km *clusterer) centroidShifts(newCentroids []*mat.VecDense) (shifts []float64, maxShift float64) {
+	shifts = make([]float64, km.clusterCnt)
+	parallelFor(km.clusterCnt, km.workers, func(start, end int) {
+		for c := start; c < end; c++ {
+			shifts[c] = km.distFn(km.centroids[c], newCentroids[c])
+		}
+	})
+
+	for c := range shifts {
+		maxShift = math.Max(maxShift, shifts[c])
+	}
+	return shifts, maxShift
+}
+
+// skipIterations returns true, and records the reason for stopping, if maxIterations is 0: the vectors are
+// only assigned to the initial centroids.
+func (km *clusterer) skipIterations() bool {
+	if km.maxIterations > 0 {
+		return false
+	}
+	km.convergenceReason = kmeans.MaxIterationsReached
+	return true
+}
+
+// isConverged checks if the algorithm has converged and records the reason for stopping.
+// The run stops once maxIterations iterations were executed. Otherwise, the first iteration is never
+// considered converged, as the centroids are yet to be recomputed from the initial assignments.
+func (km *clusterer) isConverged(iter int, changes int, maxShift float64) bool {
+	var sse float64
+	if km.convergenceType == kmeans.SSEImprovement {
+		// like the SSE of the statistics, it is not counted as a distance computation of the run.
+		sse = km.sse(km.uncountedDistFn())
+		defer func() { km.prevSSE = sse }()
+	}
+
+	if iter+1 >= km.maxIterations {
+		km.convergenceReason = kmeans.MaxIterationsReached
+		return true
+	}
+
+	if iter == 0 {
+		return false
+	}
+
+	if changes == 0 {
+		km.convergenceReason = kmeans.NoReassignments
+		return true
+	}
+
+	switch km.convergenceType {
+	case kmeans.ReassignmentRatio:
+		if float64(changes) < float64(km.vectorCnt)*km.deltaThreshold {
+			km.convergenceReason = kmeans.ReassignmentRatioBelowThreshold
+			return true
+		}
+	case kmeans.CentroidShift:
+		if maxShift < km.deltaThreshold {
+			km.convergenceReason = kmeans.CentroidShiftBelowThreshold
+			return true
+		}
+	case kmeans.SSEImprovement:
+		if km.prevSSE == 0 || (km.prevSSE-sse)/km.prevSSE < km.deltaThreshold {
+			km.convergenceReason = kmeans.SSEImprovementBelowThreshold
+			return true
+		}
+	}
+	return false
+}
+
+// SSE returns the sum of squared errors.
+func (km *clusterer) SSE() float64 {
+	return km.sse(km.distFn)
+}
+
+// sse returns the sum of squared errors, computed with distFn.
+func (km *clusterer) sse(distFn kmeans.DistanceFunction) float64 {
+	sse := 0.0
+	for i := range km.vectorList {
+		distErr := distFn(km.vectorList[i], km.centroids[km.assignments[i]])
+		sse += math.Pow(distErr, 2)
+	}
+	return sse
+}
+
+// Result returns the outcome of the last Cluster call: the centroids, the label of every input vector,
+// per-cluster sizes and SSE, and the iteration count with the reason for stopping.
+// It returns nil if Cluster has not been called yet.
+func (km *clusterer) Result() *kmeans.ClusterResult {
+	if km.centroids == nil {
+		return nil
+	}
+
+	labels := make([]int, km.vectorCnt)
+	copy(labels, km.assignments)
+
+	clusterSizes := make([]int64, km.clusterCnt)
+	clusterSSE := make([]float64, km.clusterCnt)
+	sse := 0.0
+	for x, cx := range labels {
+		distErr := km.distFn(km.vectorList[x], km.centroids[cx])
+		sqErr := math.Pow(distErr, 2)
+		clusterSizes[cx]++
+		clusterSSE[cx] += sqErr
+		sse += sqErr
+	}
+
+	return &kmeans.ClusterResult{
+		Centroids:         moarray2.ToMoArrays[float64](km.centroids),
+		Labels:            labels,
+		ClusterSizes:      clusterSizes,
+		ClusterSSE:        clusterSSE,
+		SSE:               sse,
+		Iterations:        km.iterations,
+		ConvergenceReason: km.convergenceReason,
+
+		EmptyClusterReseeds: km.emptyClusterReseeds,
+	}
+}
+
+// Predict returns the index of the nearest trained centroid for each of the input vectors.
+// It must be called after Cluster. If the clusterer normalizes the vectors, the input vectors are normalized too.
+func (km *clusterer) Predict(vectors [][]float64) ([]int, error) {
+	if km.centroids == nil {
+		return nil, moerr.NewInternalErrorNoCtx("clusterer is not trained yet")
+	}
+	return newAssigner(km.centroids, km.distFn, km.normalize, km.workers).Assign(vectors)
+}
+
+// PredictTopN returns, for each of the input vectors, the indices of the n nearest trained centroids and the
+// distances to them, sorted by increasing distance. It must be called after Cluster.
+func (km *clusterer) PredictTopN(vectors [][]float64, n int) ([][]int, [][]float64, error) {
+	if km.centroids == nil {
+		return nil, nil, moerr.NewInternalErrorNoCtx("clusterer is not trained yet")
+	}
+	return newAssigner(km.centroids, km.distFn, km.normalize, km.workers).AssignTopN(vectors, n)
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"reflect"
+	"testing"
+)
+
+func TestNewClusterer(t *testing.T) {
+	vectorList := [][]float64{{1}, {3}, {10}, {12}}
+	tests := []struct {
+		name      string
+		algorithm kmeans.Algorithm
+		wantType  kmeans.Clusterer
+		approxSSE bool // the centroids are only close to the cluster means.
+		wantErr   bool
+	}{
+		{
+			name:      "Test 1 - Elkan",
+			algorithm: kmeans.Elkan,
+			wantType:  &ElkanClusterer{},
+		},
+		{
+			name:      "Test 2 - Hamerly",
+			algorithm: kmeans.Hamerly,
+			wantType:  &HamerlyClusterer{},
+		},
+		{
+			name:      "Test 3 - Yinyang",
+			algorithm: kmeans.Yinyang,
+			wantType:  &YinyangClusterer{},
+		},
+		{
+			name:      "Test 4 - Lloyd",
+			algorithm: kmeans.Lloyd,
+			wantType:  &LloydClusterer{},
+		},
+		{
+			name:      "Test 5 - MiniBatch",
+			algorithm: kmeans.MiniBatch,
+			wantType:  &MiniBatchClusterer{},
+			approxSSE: true,
+		},
+		{
+			name:      "Test 6 - Invalid algorithm",
+			algorithm: kmeans.Algorithm(100),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, err := NewClusterer(vectorList, 2, WithAlgorithm(tt.algorithm))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewClusterer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if km != nil {
+					t.Errorf("NewClusterer() got = %v, want nil", km)
+				}
+				return
+			}
+			if reflect.TypeOf(km) != reflect.TypeOf(tt.wantType) {
+				t.Errorf("NewClusterer() got type %T, want %T", km, tt.wantType)
+			}
+			if _, err = km.Cluster(); err != nil {
+				t.Fatalf("Cluster() error = %v", err)
+			}
+			if tt.approxSSE {
+				if km.SSE() < 4 || km.SSE() > 4*1.1 {
+					t.Errorf("SSE() got = %v, want within 10%% of %v", km.SSE(), 4)
+				}
+			} else if !assertx.InEpsilonF64(4, km.SSE()) {
+				t.Errorf("SSE() got = %v, want %v", km.SSE(), 4)
+			}
+		})
+	}
+}
+
+func TestClusterer_MaxIterations(t *testing.T) {
+	data := make([][]float64, 300)
+	populateRandData(300, 4, data)
+	algorithms := []kmeans.Algorithm{kmeans.Elkan, kmeans.Hamerly, kmeans.Yinyang, kmeans.Lloyd, kmeans.MiniBatch}
+
+	for _, algorithm := range algorithms {
+		for _, maxIterations := range []int{0, 1, 2, 5} {
+			km, err := NewClusterer(data, 8, WithAlgorithm(algorithm), WithInit(kmeans.Random),
+				WithMaxIter(maxIterations), WithBatchSize(32), WithMaxNoImprovement(0))
+			if err != nil {
+				t.Fatalf("%v: NewClusterer() error = %v", algorithm, err)
+			}
+			if _, err = km.Cluster(); err != nil {
+				t.Fatalf("%v: Cluster() error = %v", algorithm, err)
+			}
+
+			res := km.(interface{ Result() *kmeans.ClusterResult }).Result()
+			if res.Iterations > maxIterations {
+				t.Errorf("%v: Iterations got = %v, want <= %v", algorithm, res.Iterations, maxIterations)
+			}
+			if res.ConvergenceReason == kmeans.MaxIterationsReached && res.Iterations != maxIterations {
+				t.Errorf("%v: Iterations got = %v with %v, want %v",
+					algorithm, res.Iterations, res.ConvergenceReason, maxIterations)
+			}
+			for x, label := range res.Labels {
+				if label < 0 || label >= 8 {
+					t.Fatalf("%v: Labels[%v] got = %v", algorithm, x, label)
+				}
+			}
+		}
+	}
+}
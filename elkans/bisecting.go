@@ -0,0 +1,302 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"context"
+	"github.com/arjunsk/kmeans"
+	moarray2 "github.com/arjunsk/kmeans/utils/moarray"
+	"github.com/arjunsk/kmeans/utils/moerr"
+	"gonum.org/v1/gonum/mat"
+	"math"
+)
+
+// BisectingClusterer is bisecting kmeans. Starting from a single cluster of all the vectors, it repeatedly
+// splits the cluster with the largest SSE in two with a 2-means ElkanClusterer run, until there are k
+// clusters. Besides the flat clustering, it keeps the binary tree of the splits (see Tree), which can be cut
+// at any k' <= k afterwards, or probed from coarse to fine clusters.
+//
+// The options are passed to every 2-means run, except WithInitialCentroids which is not supported, and
+// WithAlgorithm and WithYinyangGroups which do not apply to an ElkanClusterer. The seed of a run is the
+// configured seed plus the split order, so that the splits draw different vectors.
+//
+// Ref Paper: https://www.cs.cmu.edu/~dunja/KDDpapers/Steinbach_IR.pdf
+type BisectingClusterer struct {
+	elkanRuns
+	clusterCnt int
+
+	tree                *kmeans.ClusterTreeNode
+	labels              []int
+	iterations          int
+	emptyClusterReseeds int
+}
+
+var _ kmeans.Clusterer = new(BisectingClusterer)
+
+// NewBisectingClusterer returns a BisectingClusterer for clustering vectors into clusterCnt clusters.
+// The defaults of the 2-means runs can be overridden using the With* options.
+func NewBisectingClusterer(vectors [][]float64, clusterCnt int, opts ...Option) (*BisectingClusterer, error) {
+	runs, _, err := newElkanRuns("bisecting kmeans", vectors, clusterCnt, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &BisectingClusterer{elkanRuns: runs, clusterCnt: clusterCnt}, nil
+}
+
+// InitCentroids does nothing: the centroids of each split are initialized by its 2-means run.
+func (km *BisectingClusterer) InitCentroids() error {
+	return nil
+}
+
+// Cluster returns the final centroids and the error if any.
+func (km *BisectingClusterer) Cluster() ([][]float64, error) {
+	return km.ClusterContext(context.Background())
+}
+
+// ClusterContext is like Cluster, but stops when ctx is done and returns its error.
+// It fails if the vectors cannot be split into k clusters, i.e. if there are less than k distinct vectors.
+func (km *BisectingClusterer) ClusterContext(ctx context.Context) ([][]float64, error) {
+	km.tree, km.labels = nil, nil
+	km.iterations, km.emptyClusterReseeds = 0, 0
+
+	root := newRootNode(km.vectorList, km.distFn)
+	leaves := []*kmeans.ClusterTreeNode{root}
+	unsplittable := make(map[*kmeans.ClusterTreeNode]bool)
+	for order := 0; len(leaves) < km.clusterCnt; {
+		// ties go to the leftmost leaf.
+		best := -1
+		for i, leaf := range leaves {
+			if !unsplittable[leaf] && leaf.Size > 1 && leaf.SSE > 0 && (best == -1 || leaf.SSE > leaves[best].SSE) {
+				best = i
+			}
+		}
+		if best == -1 {
+			return nil, moerr.NewInternalErrorNoCtx("only %d of %d clusters could be split", len(leaves), km.clusterCnt)
+		}
+
+		split, err := km.split(ctx, leaves[best], order)
+		if err != nil {
+			return nil, err
+		}
+		if !split {
+			unsplittable[leaves[best]] = true
+			continue
+		}
+
+		children := leaves[best].Children
+		leaves = append(leaves[:best], append(children, leaves[best+1:]...)...)
+		order++
+	}
+
+	var centroids [][]float64
+	km.labels, centroids = labelLeaves(leaves, len(km.vectors))
+	km.tree = root
+	return centroids, nil
+}
+
+// split divides node in two with a 2-means run, and returns false if one of the halves is empty.
+func (km *BisectingClusterer) split(ctx context.Context, node *kmeans.ClusterTreeNode, order int) (bool, error) {
+	twoMeans, err := km.newRun(km.subset(node.Members), 2, km.seed+int64(order))
+	if err != nil {
+		return false, err
+	}
+	if _, err = twoMeans.ClusterContext(ctx); err != nil {
+		return false, err
+	}
+	result := twoMeans.Result()
+	km.iterations += result.Iterations
+	km.emptyClusterReseeds += result.EmptyClusterReseeds
+
+	children := newChildNodes(node, result)
+	if len(children) < 2 {
+		return false, nil
+	}
+	node.Children = children
+	node.SplitOrder = order
+	return true, nil
+}
+
+// Tree returns the tree of the splits, or nil before Cluster.
+func (km *BisectingClusterer) Tree() *kmeans.ClusterTreeNode {
+	return km.tree
+}
+
+// SSE returns the sum of squared errors of the flat clustering.
+func (km *BisectingClusterer) SSE() float64 {
+	if km.tree == nil {
+		return 0
+	}
+	return km.Result().SSE
+}
+
+// Result returns the flat clustering, or nil before Cluster. Iterations and EmptyClusterReseeds add up the
+// 2-means runs, and ConvergenceReason is not set, as each run stops on its own.
+func (km *BisectingClusterer) Result() *kmeans.ClusterResult {
+	if km.tree == nil {
+		return nil
+	}
+	return newTreeResult(km.tree, km.labels, km.iterations, km.emptyClusterReseeds)
+}
+
+// elkanRuns holds what the clusterers made of ElkanClusterer runs share: the vectors, and the options and
+// the seed of the runs.
+type elkanRuns struct {
+	vectors    [][]float64
+	vectorList []*mat.VecDense // the vectors, normalized if needed.
+	opts       []Option
+	seed       int64
+	distFn     kmeans.DistanceFunction
+}
+
+// newElkanRuns validates the vectors and the options of the runs of clusterer name into k clusters, and
+// returns them with the resolved options. The runs are always ElkanClusterer runs, so WithAlgorithm and
+// WithYinyangGroups are reset to their defaults instead of failing the runs.
+func newElkanRuns(name string, vectors [][]float64, k int, opts []Option) (elkanRuns, options, error) {
+	opts = append(append([]Option{}, opts...), WithAlgorithm(kmeans.Elkan), WithYinyangGroups(0))
+	cfg := newOptions(opts)
+	if err := validateArgs(vectors, k, cfg); err != nil {
+		return elkanRuns{}, cfg, err
+	}
+	if cfg.initialCentroids != nil {
+		return elkanRuns{}, cfg, moerr.NewInternalErrorNoCtx("initial centroids are not supported by %s", name)
+	}
+
+	gonumVectors, err := moarray2.ToGonumVectors[float64](vectors...)
+	if err != nil {
+		return elkanRuns{}, cfg, err
+	}
+	if cfg.normalize {
+		moarray2.NormalizeGonumVectors(gonumVectors)
+	}
+
+	distanceFunction, err := resolveDistanceFn(cfg.distanceType)
+	if err != nil {
+		return elkanRuns{}, cfg, err
+	}
+
+	return elkanRuns{
+		vectors:    vectors,
+		vectorList: gonumVectors,
+		opts:       opts,
+		seed:       cfg.seed,
+		distFn:     distanceFunction,
+	}, cfg, nil
+}
+
+// subset returns the vectors of members.
+func (r *elkanRuns) subset(members []int) [][]float64 {
+	subset := make([][]float64, len(members))
+	for i, x := range members {
+		subset[i] = r.vectors[x]
+	}
+	return subset
+}
+
+// newRun returns an ElkanClusterer of vectors into k clusters with the options of the runs, seeded with seed.
+// The extra options are applied last.
+func (r *elkanRuns) newRun(vectors [][]float64, k int, seed int64, extra ...Option) (*ElkanClusterer, error) {
+	opts := append(append([]Option{}, r.opts...), WithSeed(seed))
+	return NewElkanClusterer(vectors, k, append(opts, extra...)...)
+}
+
+// newRootNode returns the tree node of all the vectors, with their mean as centroid.
+func newRootNode(vectors []*mat.VecDense, distFn kmeans.DistanceFunction) *kmeans.ClusterTreeNode {
+	members := make([]int, len(vectors))
+	mean := mat.NewVecDense(vectors[0].Len(), nil)
+	for x, v := range vectors {
+		members[x] = x
+		mean.AddVec(mean, v)
+	}
+	mean.ScaleVec(1/float64(len(vectors)), mean)
+
+	var sse float64
+	for _, v := range vectors {
+		sse += math.Pow(distFn(v, mean), 2)
+	}
+	return &kmeans.ClusterTreeNode{
+		Centroid:   moarray2.ToMoArray[float64](mean),
+		Members:    members,
+		Size:       int64(len(members)),
+		SSE:        sse,
+		SplitOrder: -1,
+		LeafID:     -1,
+	}
+}
+
+// newChildNodes returns the tree nodes of the non-empty clusters of result, a clustering of the members of
+// node. They are in the order of the clusters.
+func newChildNodes(node *kmeans.ClusterTreeNode, result *kmeans.ClusterResult) []*kmeans.ClusterTreeNode {
+	clusters := make([]*kmeans.ClusterTreeNode, len(result.Centroids))
+	for c := range clusters {
+		clusters[c] = &kmeans.ClusterTreeNode{
+			Centroid:   result.Centroids[c],
+			Members:    make([]int, 0, result.ClusterSizes[c]),
+			Size:       result.ClusterSizes[c],
+			SSE:        result.ClusterSSE[c],
+			Depth:      node.Depth + 1,
+			SplitOrder: -1,
+			LeafID:     -1,
+		}
+	}
+	for i, x := range node.Members {
+		clusters[result.Labels[i]].Members = append(clusters[result.Labels[i]].Members, x)
+	}
+
+	var children []*kmeans.ClusterTreeNode
+	for _, cluster := range clusters {
+		if cluster.Size > 0 {
+			children = append(children, cluster)
+		}
+	}
+	return children
+}
+
+// labelLeaves sets the LeafID of the leaves to their index, and returns the label of each of the n vectors
+// and copies of the leaf centroids.
+func labelLeaves(leaves []*kmeans.ClusterTreeNode, n int) (labels []int, centroids [][]float64) {
+	labels = make([]int, n)
+	centroids = make([][]float64, len(leaves))
+	for c, leaf := range leaves {
+		leaf.LeafID = c
+		centroids[c] = append([]float64(nil), leaf.Centroid...)
+		for _, x := range leaf.Members {
+			labels[x] = c
+		}
+	}
+	return labels, centroids
+}
+
+// newTreeResult returns the clustering of the leaves of tree. ConvergenceReason is not set, as each kmeans
+// run of the tree stops on its own.
+func newTreeResult(tree *kmeans.ClusterTreeNode, labels []int, iterations, emptyClusterReseeds int) *kmeans.ClusterResult {
+	leaves := tree.Leaves()
+	result := &kmeans.ClusterResult{
+		Centroids:    make([][]float64, len(leaves)),
+		Labels:       make([]int, len(labels)),
+		ClusterSizes: make([]int64, len(leaves)),
+		ClusterSSE:   make([]float64, len(leaves)),
+		Iterations:   iterations,
+
+		EmptyClusterReseeds: emptyClusterReseeds,
+	}
+	copy(result.Labels, labels)
+	for c, leaf := range leaves {
+		result.Centroids[c] = append([]float64(nil), leaf.Centroid...)
+		result.ClusterSizes[c] = leaf.Size
+		result.ClusterSSE[c] = leaf.SSE
+		result.SSE += leaf.SSE
+	}
+	return result
+}
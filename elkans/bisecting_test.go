@@ -0,0 +1,184 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestBisectingClusterer_Cluster(t *testing.T) {
+	vectorList := [][]float64{
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+	}
+	km, err := NewBisectingClusterer(vectorList, 2)
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	got, err := km.Cluster()
+	if err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+	want := [][]float64{
+		{1, 2, 3.6666666666666665, 4.666666666666666},
+		{10, 3.333333333333333, 4, 5},
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i][0] < got[j][0] })
+	if !assertx.InEpsilonF64Slices(want, got) {
+		t.Errorf("Cluster() got = %v, want %v", got, want)
+	}
+	if !assertx.InEpsilonF64(12, km.SSE()) {
+		t.Errorf("SSE() got = %v, want %v", km.SSE(), 12)
+	}
+}
+
+func TestBisectingClusterer_Tree(t *testing.T) {
+	data := blobs(400, 4, 3, 1)
+	km, err := NewBisectingClusterer(data, 4)
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	if km.Tree() != nil || km.Result() != nil {
+		t.Errorf("Tree() and Result() before Cluster(), want nil")
+	}
+	if _, err = km.Cluster(); err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+
+	tree := km.Tree()
+	if tree.Size != 400 || tree.Depth != 0 || tree.SplitOrder != 0 {
+		t.Errorf("Tree() root got Size=%d Depth=%d SplitOrder=%d, want 400, 0, 0", tree.Size, tree.Depth, tree.SplitOrder)
+	}
+	var check func(node *kmeans.ClusterTreeNode)
+	check = func(node *kmeans.ClusterTreeNode) {
+		if int64(len(node.Members)) != node.Size {
+			t.Errorf("node Size got = %d, want %d members", node.Size, len(node.Members))
+		}
+		if node.IsLeaf() {
+			if node.SplitOrder != -1 || node.LeafID == -1 {
+				t.Errorf("leaf got SplitOrder=%d LeafID=%d", node.SplitOrder, node.LeafID)
+			}
+			return
+		}
+		if len(node.Children) != 2 || node.LeafID != -1 {
+			t.Errorf("inner node got %d children and LeafID=%d", len(node.Children), node.LeafID)
+		}
+		var members []int
+		for _, child := range node.Children {
+			if child.Depth != node.Depth+1 || (!child.IsLeaf() && child.SplitOrder <= node.SplitOrder) {
+				t.Errorf("child got Depth=%d SplitOrder=%d, parent Depth=%d SplitOrder=%d",
+					child.Depth, child.SplitOrder, node.Depth, node.SplitOrder)
+			}
+			members = append(members, child.Members...)
+			check(child)
+		}
+		sort.Ints(members)
+		if !reflect.DeepEqual(members, node.Members) {
+			t.Errorf("children members do not partition the node members")
+		}
+	}
+	check(tree)
+
+	// each blob ends up in its own leaf.
+	result := km.Result()
+	leaves := tree.Leaves()
+	if len(leaves) != 4 {
+		t.Fatalf("Leaves() got %d leaves, want 4", len(leaves))
+	}
+	for c, leaf := range leaves {
+		if leaf.LeafID != c || leaf.Size != 100 {
+			t.Errorf("Leaves()[%d] got LeafID=%d Size=%d, want %d, 100", c, leaf.LeafID, leaf.Size, c)
+		}
+		for _, x := range leaf.Members {
+			if result.Labels[x] != c || x%4 != leaf.Members[0]%4 {
+				t.Errorf("Leaves()[%d] holds vector %d of blob %d", c, x, x%4)
+			}
+		}
+	}
+
+	for k := 1; k <= 5; k++ {
+		want := k
+		if want > 4 {
+			want = 4
+		}
+		if got := tree.Cut(k); len(got) != want {
+			t.Errorf("Cut(%d) got %d clusters, want %d", k, len(got), want)
+		}
+	}
+	if got := tree.Cut(2); !reflect.DeepEqual(got, tree.Children) {
+		t.Errorf("Cut(2) got = %v, want the children of the root", got)
+	}
+}
+
+func TestNewBisectingClusterer(t *testing.T) {
+	tests := []struct {
+		name       string
+		vectors    [][]float64
+		clusterCnt int
+		opts       []Option
+		wantErr    bool
+	}{
+		{
+			name:       "Test 1 - valid",
+			vectors:    [][]float64{{1}, {2}, {10}, {11}},
+			clusterCnt: 3,
+		},
+		{
+			name:       "Test 2 - initial centroids",
+			vectors:    [][]float64{{1}, {2}, {10}, {11}},
+			clusterCnt: 2,
+			opts:       []Option{WithInitialCentroids([][]float64{{1}, {10}})},
+			wantErr:    true,
+		},
+		{
+			name:       "Test 3 - less distinct vectors than clusters",
+			vectors:    [][]float64{{1}, {1}, {1}, {2}},
+			clusterCnt: 3,
+			wantErr:    true,
+		},
+		{
+			// the 2-means runs are ElkanClusterer runs, so the yinyang groups do not apply to them.
+			name:       "Test 4 - yinyang groups above the run cluster count",
+			vectors:    [][]float64{{1}, {2}, {10}, {11}},
+			clusterCnt: 3,
+			opts:       []Option{WithAlgorithm(kmeans.Yinyang), WithYinyangGroups(3)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, err := NewBisectingClusterer(tt.vectors, tt.clusterCnt, tt.opts...)
+			if err == nil {
+				_, err = km.Cluster()
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewBisectingClusterer() and Cluster() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -15,13 +15,12 @@
 package elkans
 
 import (
+	"context"
 	"github.com/arjunsk/kmeans"
-	moarray2 "github.com/arjunsk/kmeans/utils/moarray"
-	"github.com/arjunsk/kmeans/utils/moerr"
 	"gonum.org/v1/gonum/mat"
 	"math"
-	"math/rand"
-	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // ElkanClusterer is an improved kmeans algorithm which using the triangle inequality to reduce the number of
@@ -34,32 +33,18 @@ import (
 // These updates take O(nk) time, so the complexity of the algorithm remains at least O(nke), even though the number
 // of distance calculations is roughly O(n) only.
 // NOTE that, distance calculation is very expensive for higher dimension vectors.
+// The bounds take n*k memory; HamerlyClusterer keeps a single lower bound per vector instead.
 //
 // Ref Paper: https://cdn.aaai.org/ICML/2003/ICML03-022.pdf
 type ElkanClusterer struct {
+	clusterer
 
 	// for each of the n vectors, we keep track of the following data
-	vectorList  []*mat.VecDense
 	vectorMetas []vectorMeta
-	assignments []int
 
 	// for each of the k centroids, we keep track of the following data
-	centroids                   []*mat.VecDense
 	halfInterCentroidDistMatrix [][]float64
 	minHalfInterCentroidDist    []float64
-
-	// thresholds
-	maxIterations  int     // e in paper
-	deltaThreshold float64 // used for early convergence. we are not using it right now.
-
-	// counts
-	clusterCnt int // k in paper
-	vectorCnt  int // n in paper
-
-	distFn    kmeans.DistanceFunction
-	initType  kmeans.InitType
-	rand      *rand.Rand
-	normalize bool
 }
 
 // vectorMeta holds required information for Elkan's kmeans pruning.
@@ -76,24 +61,47 @@ type vectorMeta struct {
 
 var _ kmeans.Clusterer = new(ElkanClusterer)
 
+// NewKMeans returns an ElkanClusterer configured with the given positional arguments.
+// It is kept for compatibility; use NewElkanClusterer to configure the other options.
 func NewKMeans(vectors [][]float64, clusterCnt,
 	maxIterations int, deltaThreshold float64,
 	distanceType kmeans.DistanceType, initType kmeans.InitType,
 	normalize bool,
 ) (kmeans.Clusterer, error) {
-
-	err := validateArgs(vectors, clusterCnt, maxIterations, deltaThreshold, distanceType, initType)
+	km, err := NewElkanClusterer(vectors, clusterCnt,
+		WithMaxIter(maxIterations),
+		WithTolerance(deltaThreshold),
+		WithDistance(distanceType),
+		WithInit(initType),
+		WithNormalize(normalize),
+	)
 	if err != nil {
 		return nil, err
 	}
+	return km, nil
+}
 
-	gonumVectors, err := moarray2.ToGonumVectors[float64](vectors...)
+// NewElkanClusterer returns an ElkanClusterer for clustering vectors into clusterCnt clusters.
+// The defaults can be overridden using the With* options.
+func NewElkanClusterer(vectors [][]float64, clusterCnt int, opts ...Option) (*ElkanClusterer, error) {
+	base, err := newClusterer(vectors, clusterCnt, newOptions(opts))
 	if err != nil {
 		return nil, err
 	}
 
-	assignments := make([]int, len(vectors))
-	var metas = make([]vectorMeta, len(vectors))
+	km := &ElkanClusterer{
+		clusterer:   base,
+		vectorMetas: newVectorMetas(len(vectors), clusterCnt),
+
+		halfInterCentroidDistMatrix: newCentroidDistMatrix(clusterCnt),
+		minHalfInterCentroidDist:    make([]float64, clusterCnt),
+	}
+	km.algo = km
+	return km, nil
+}
+
+func newVectorMetas(vectorCnt, clusterCnt int) []vectorMeta {
+	var metas = make([]vectorMeta, vectorCnt)
 	for i := range metas {
 		metas[i] = vectorMeta{
 			lower:     make([]float64, clusterCnt),
@@ -101,155 +109,112 @@ func NewKMeans(vectors [][]float64, clusterCnt,
 			recompute: true,
 		}
 	}
+	return metas
+}
 
+func newCentroidDistMatrix(clusterCnt int) [][]float64 {
 	centroidDist := make([][]float64, clusterCnt)
 	for i := range centroidDist {
 		centroidDist[i] = make([]float64, clusterCnt)
 	}
-	minCentroidDist := make([]float64, clusterCnt)
-
-	distanceFunction, err := resolveDistanceFn(distanceType)
-	if err != nil {
-		return nil, err
-	}
-
-	return &ElkanClusterer{
-		maxIterations:  maxIterations,
-		deltaThreshold: deltaThreshold,
-
-		vectorList:  gonumVectors,
-		assignments: assignments,
-		vectorMetas: metas,
-
-		//centroids will be initialized by InitCentroids()
-		halfInterCentroidDistMatrix: centroidDist,
-		minHalfInterCentroidDist:    minCentroidDist,
-
-		distFn:     distanceFunction,
-		initType:   initType,
-		clusterCnt: clusterCnt,
-		vectorCnt:  len(vectors),
-
-		rand:      rand.New(rand.NewSource(kmeans.DefaultRandSeed)),
-		normalize: normalize,
-	}, nil
+	return centroidDist
 }
 
-// InitCentroids initializes the centroids using initialization algorithms like random or kmeans++.
-func (km *ElkanClusterer) InitCentroids() error {
-	var initializer Initializer
-	switch km.initType {
-	case kmeans.Random:
-		initializer = NewRandomInitializer()
-	case kmeans.KmeansPlusPlus:
-		initializer = NewKMeansPlusPlusInitializer(km.distFn)
-	default:
-		initializer = NewRandomInitializer()
-	}
-	km.centroids = initializer.InitCentroids(km.vectorList, km.clusterCnt)
-	return nil
+func (km *ElkanClusterer) base() *clusterer {
+	return &km.clusterer
 }
 
-// Cluster returns the final centroids and the error if any.
-func (km *ElkanClusterer) Cluster() ([][]float64, error) {
-	if km.normalize {
-		moarray2.NormalizeGonumVectors(km.vectorList)
-	}
+// newRun returns a single-run copy of km, seeded with seed and with its own bounds and centroids.
+func (km *ElkanClusterer) newRun(seed int64) algorithm {
+	run := &ElkanClusterer{
+		clusterer:   km.clusterer.newRun(seed),
+		vectorMetas: newVectorMetas(km.vectorCnt, km.clusterCnt),
 
-	if km.vectorCnt == km.clusterCnt {
-		return moarray2.ToMoArrays[float64](km.vectorList), nil
+		halfInterCentroidDistMatrix: newCentroidDistMatrix(km.clusterCnt),
+		minHalfInterCentroidDist:    make([]float64, km.clusterCnt),
 	}
+	run.algo = run
+	return run
+}
 
-	err := km.InitCentroids() // step 0.1
-	if err != nil {
-		return nil, err
+// iterate runs Elkan's kmeans from the initial centroids.
+func (km *ElkanClusterer) iterate(ctx context.Context) error {
+	start := time.Now()
+	if err := km.initBounds(ctx); err != nil { // step 0.2
+		return err
 	}
-
-	km.initBounds() // step 0.2
-
-	res, err := km.elkansCluster()
-	if err != nil {
-		return nil, err
+	km.phases.InitBounds += lap(&start)
+	if km.skipIterations() {
+		return nil
 	}
-
-	return moarray2.ToMoArrays[float64](res), nil
+	return km.elkansCluster(ctx)
 }
 
-func (km *ElkanClusterer) elkansCluster() ([]*mat.VecDense, error) {
-
+func (km *ElkanClusterer) elkansCluster(ctx context.Context) error {
 	for iter := 0; ; iter++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		iterStart := km.startIteration()
+		start := iterStart
+
 		km.computeCentroidDistances() // step 1
+		km.phases.CentroidDistances += lap(&start)
 
-		changes := km.assignData() // step 2 and 3
+		changes, err := km.assignData(ctx) // step 2 and 3
+		if err != nil {
+			return err
+		}
+		km.phases.Assignment += lap(&start)
 
 		newCentroids := km.recalculateCentroids() // step 4
+		km.phases.Recalculation += lap(&start)
 
-		km.updateBounds(newCentroids) // step 5 and 6
+		maxShift := km.updateBounds(newCentroids) // step 5 and 6
+		km.phases.BoundUpdate += lap(&start)
 
 		km.centroids = newCentroids // step 7
 
+		km.iterations = iter + 1
+		km.recordIteration(changes, maxShift, iterStart)
+
 		//logutil.Debugf("kmeans iter=%d, changes=%d", iter, changes)
-		if iter != 0 && km.isConverged(iter, changes) {
+		if km.isConverged(iter, changes, maxShift) {
 			break
 		}
 	}
-	return km.centroids, nil
-}
-
-func validateArgs(vectorList [][]float64, clusterCnt,
-	maxIterations int, deltaThreshold float64,
-	distanceType kmeans.DistanceType, initType kmeans.InitType) error {
-	if len(vectorList) == 0 || len(vectorList[0]) == 0 {
-		return moerr.NewInternalErrorNoCtx("input vectors is empty")
-	}
-	if clusterCnt > len(vectorList) {
-		return moerr.NewInternalErrorNoCtx("cluster count is larger than vector count %d > %d", clusterCnt, len(vectorList))
-	}
-	if maxIterations < 0 {
-		return moerr.NewInternalErrorNoCtx("max iteration is out of bounds (must be >= 0)")
-	}
-	if deltaThreshold <= 0.0 || deltaThreshold >= 1.0 {
-		return moerr.NewInternalErrorNoCtx("delta threshold is out of bounds (must be > 0.0 and < 1.0)")
-	}
-	if distanceType > 2 {
-		return moerr.NewInternalErrorNoCtx("distance type is not supported")
-	}
-	if initType > 1 {
-		return moerr.NewInternalErrorNoCtx("init type is not supported")
-	}
-
-	// We need to validate that all vectors have the same dimension.
-	// This is already done by moarray.ToGonumVectors, so skipping it here.
-
-	if (clusterCnt * clusterCnt) > math.MaxInt {
-		return moerr.NewInternalErrorNoCtx("cluster count is too large for int*int")
-	}
-
 	return nil
 }
 
 // initBounds initializes the lower bounds, upper bound and assignment for each vector.
-func (km *ElkanClusterer) initBounds() {
+func (km *ElkanClusterer) initBounds(ctx context.Context) error {
 	// step 0.2
 	// Set the lower bound l(x, c)=0 for each point x and center c.
 	// Assign each x to its closest initial center c(x)=min{ d(x, c) }, using Lemma 1 to avoid
 	// redundant distance calculations. Each time d(x, c) is computed, set l(x, c)=d(x, c).
 	// Assign upper bounds u(x)=min_c d(x, c).
-	for x := range km.vectorList {
-		minDist := math.MaxFloat64
-		closestCenter := 0
-		for c := range km.centroids {
-			dist := km.distFn(km.vectorList[x], km.centroids[c])
-			km.vectorMetas[x].lower[c] = dist
-			if dist < minDist {
-				minDist = dist
-				closestCenter = c
+	parallelFor(km.vectorCnt, km.workers, func(start, end int) {
+		for x := start; x < end; x++ {
+			if (x-start)%ctxCheckInterval == 0 && ctx.Err() != nil {
+				return
 			}
-		}
 
-		km.vectorMetas[x].upper = minDist
-		km.assignments[x] = closestCenter
-	}
+			minDist := math.MaxFloat64
+			closestCenter := 0
+			for c := range km.centroids {
+				dist := km.distFn(km.vectorList[x], km.centroids[c])
+				km.vectorMetas[x].lower[c] = dist
+				if dist < minDist {
+					minDist = dist
+					closestCenter = c
+				}
+			}
+
+			km.vectorMetas[x].upper = minDist
+			km.assignments[x] = closestCenter
+		}
+	})
+	return ctx.Err()
 }
 
 // computeCentroidDistances computes the centroid distances and the min centroid distances.
@@ -258,19 +223,15 @@ func (km *ElkanClusterer) computeCentroidDistances() {
 
 	// step 1.a
 	// For all centers c and c', compute 0.5 x d(c, c').
-	var wg sync.WaitGroup
-	for r := 0; r < km.clusterCnt; r++ {
-		for c := r + 1; c < km.clusterCnt; c++ {
-			wg.Add(1)
-			go func(i, j int) {
-				defer wg.Done()
-				dist := 0.5 * km.distFn(km.centroids[i], km.centroids[j])
-				km.halfInterCentroidDistMatrix[i][j] = dist
-				km.halfInterCentroidDistMatrix[j][i] = dist
-			}(r, c)
+	parallelFor(km.clusterCnt, km.workers, func(start, end int) {
+		for r := start; r < end; r++ {
+			for c := r + 1; c < km.clusterCnt; c++ {
+				dist := 0.5 * km.distFn(km.centroids[r], km.centroids[c])
+				km.halfInterCentroidDistMatrix[r][c] = dist
+				km.halfInterCentroidDistMatrix[c][r] = dist
+			}
 		}
-	}
-	wg.Wait()
+	})
 
 	// step 1.b
 	//  For all centers c, compute s(c)=0.5 x min{d(c, c') | c'!= c}.
@@ -286,171 +247,134 @@ func (km *ElkanClusterer) computeCentroidDistances() {
 	}
 }
 
-// assignData assigns each vector to the nearest centroid.
+// assignData assigns each vector to the nearest centroid and returns the number of vectors that changed their cluster.
 // This is the place where most of the "distance computation skipping" happens.
-func (km *ElkanClusterer) assignData() int {
-
-	changes := 0
-
-	for currVector := range km.vectorList {
+func (km *ElkanClusterer) assignData(ctx context.Context) (int, error) {
 
-		// step 2
-		// u(x) <= s(c(x))
-		if km.vectorMetas[currVector].upper <= km.minHalfInterCentroidDist[km.assignments[currVector]] {
-			continue
-		}
-
-		for c := range km.centroids { // c is nextPossibleCentroidIdx
-			// step 3
-			// For all remaining points x and centers c such that
-			// (i) c != c(x) and
-			// (ii) u(x)>l(x, c) and
-			// (iii) u(x)> 0.5 x d(c(x), c)
-			if c != km.assignments[currVector] &&
-				km.vectorMetas[currVector].upper > km.vectorMetas[currVector].lower[c] &&
-				km.vectorMetas[currVector].upper > km.halfInterCentroidDistMatrix[km.assignments[currVector]][c] {
-
-				//step 3.a - Bounds update
-				// If r(x) then compute d(x, c(x)) and assign r(x)= false.
-				var dxcx float64
-				if km.vectorMetas[currVector].recompute {
-					km.vectorMetas[currVector].recompute = false
-
-					dxcx = km.distFn(km.vectorList[currVector], km.centroids[km.assignments[currVector]])
-					km.vectorMetas[currVector].upper = dxcx
-					km.vectorMetas[currVector].lower[km.assignments[currVector]] = dxcx
-
-					if km.vectorMetas[currVector].upper <= km.vectorMetas[currVector].lower[c] {
-						continue // Pruned by triangle inequality on lower bound.
-					}
-
-					if km.vectorMetas[currVector].upper <= km.halfInterCentroidDistMatrix[km.assignments[currVector]][c] {
-						continue // Pruned by triangle inequality on cluster distances.
-					}
-
-				} else {
-					dxcx = km.vectorMetas[currVector].upper //  Otherwise, d(x, c(x))=u(x).
-				}
+	var changes, skippedByStep2, skippedByStep3 int64
 
-				//step 3.b - Update
-				// If d(x, c(x))>l(x, c) or d(x, c(x))> 0.5 d(c(x), c) then
-				// Compute d(x, c)
-				// If d(x, c)<d(x, c(x)) then assign c(x)=c.
-				if dxcx > km.vectorMetas[currVector].lower[c] ||
-					dxcx > km.halfInterCentroidDistMatrix[km.assignments[currVector]][c] {
-
-					dxc := km.distFn(km.vectorList[currVector], km.centroids[c]) // d(x,c) in the paper
-					km.vectorMetas[currVector].lower[c] = dxc
-					if dxc < dxcx {
-						km.vectorMetas[currVector].upper = dxc
-						km.assignments[currVector] = c
-						changes++
-					}
-				}
+	parallelFor(km.vectorCnt, km.workers, func(start, end int) {
+		var chunkChanges, chunkSkipped2, chunkSkipped3 int64
+		for currVector := start; currVector < end; currVector++ {
+			if (currVector-start)%ctxCheckInterval == 0 && ctx.Err() != nil {
+				return
+			}
+			changed, computed := km.assignVector(currVector)
+			if changed {
+				chunkChanges++
+			}
+			if computed < 0 {
+				chunkSkipped2 += int64(km.clusterCnt)
+			} else {
+				chunkSkipped3 += int64(km.clusterCnt - computed)
 			}
 		}
+		atomic.AddInt64(&changes, chunkChanges)
+		atomic.AddInt64(&skippedByStep2, chunkSkipped2)
+		atomic.AddInt64(&skippedByStep3, chunkSkipped3)
+	})
+
+	if km.stats != nil {
+		km.stats.SkippedByStep2 += skippedByStep2
+		km.stats.SkippedByStep3 += skippedByStep3
 	}
-	return changes
-}
-
-// recalculateCentroids calculates the new mean centroids based on the new assignments.
-func (km *ElkanClusterer) recalculateCentroids() []*mat.VecDense {
-	membersCount := make([]int64, km.clusterCnt)
 
-	newCentroids := make([]*mat.VecDense, km.clusterCnt)
-	for c := range newCentroids {
-		newCentroids[c] = mat.NewVecDense(km.vectorList[0].Len(), nil)
+	if err := ctx.Err(); err != nil {
+		return 0, err
 	}
+	return int(changes), nil
+}
 
-	// sum of all the members of the cluster
-	for x, vec := range km.vectorList {
-		cx := km.assignments[x]
-		membersCount[cx]++
-		newCentroids[cx].AddVec(newCentroids[cx], vec)
+// assignVector runs step 2 and 3 for a single vector and returns true if the vector changed its cluster, with
+// the number of distances computed, or -1 if step 2 skipped the vector.
+func (km *ElkanClusterer) assignVector(currVector int) (changed bool, computed int) {
+	// step 2
+	// u(x) <= s(c(x))
+	if km.vectorMetas[currVector].upper <= km.minHalfInterCentroidDist[km.assignments[currVector]] {
+		return false, -1
 	}
 
-	// means of the clusters = sum of all the members of the cluster / number of members in the cluster
-	for c := range newCentroids {
-		if membersCount[c] == 0 {
-			// pick a vector randomly from existing vectors as the new centroid
-			//newCentroids[c] = km.vectorList[km.rand.Intn(km.vectorCnt)]
+	prevAssignment := km.assignments[currVector]
+
+	for c := range km.centroids { // c is nextPossibleCentroidIdx
+		// step 3
+		// For all remaining points x and centers c such that
+		// (i) c != c(x) and
+		// (ii) u(x)>l(x, c) and
+		// (iii) u(x)> 0.5 x d(c(x), c)
+		if c != km.assignments[currVector] &&
+			km.vectorMetas[currVector].upper > km.vectorMetas[currVector].lower[c] &&
+			km.vectorMetas[currVector].upper > km.halfInterCentroidDistMatrix[km.assignments[currVector]][c] {
+
+			//step 3.a - Bounds update
+			// If r(x) then compute d(x, c(x)) and assign r(x)= false.
+			var dxcx float64
+			if km.vectorMetas[currVector].recompute {
+				km.vectorMetas[currVector].recompute = false
+
+				dxcx = km.distFn(km.vectorList[currVector], km.centroids[km.assignments[currVector]])
+				computed++
+				km.vectorMetas[currVector].upper = dxcx
+				km.vectorMetas[currVector].lower[km.assignments[currVector]] = dxcx
+
+				if km.vectorMetas[currVector].upper <= km.vectorMetas[currVector].lower[c] {
+					continue // Pruned by triangle inequality on lower bound.
+				}
+
+				if km.vectorMetas[currVector].upper <= km.halfInterCentroidDistMatrix[km.assignments[currVector]][c] {
+					continue // Pruned by triangle inequality on cluster distances.
+				}
 
-			//// if the cluster is empty, reinitialize it to a random vector, since you can't find the mean of an empty set
-			randVector := make([]float64, km.vectorList[0].Len())
-			for l := range randVector {
-				randVector[l] = km.rand.Float64()
+			} else {
+				dxcx = km.vectorMetas[currVector].upper //  Otherwise, d(x, c(x))=u(x).
 			}
-			newCentroids[c] = mat.NewVecDense(km.vectorList[0].Len(), randVector)
 
-			// normalize the random vector
-			if km.normalize {
-				moarray2.NormalizeGonumVector(newCentroids[c])
+			//step 3.b - Update
+			// If d(x, c(x))>l(x, c) or d(x, c(x))> 0.5 d(c(x), c) then
+			// Compute d(x, c)
+			// If d(x, c)<d(x, c(x)) then assign c(x)=c.
+			if dxcx > km.vectorMetas[currVector].lower[c] ||
+				dxcx > km.halfInterCentroidDistMatrix[km.assignments[currVector]][c] {
+
+				dxc := km.distFn(km.vectorList[currVector], km.centroids[c]) // d(x,c) in the paper
+				computed++
+				km.vectorMetas[currVector].lower[c] = dxc
+				if dxc < dxcx {
+					km.vectorMetas[currVector].upper = dxc
+					km.assignments[currVector] = c
+				}
 			}
-		} else {
-			// find the mean of the cluster members
-			// note: we don't need to normalize here, since the vectors are already normalized
-			newCentroids[c].ScaleVec(1.0/float64(membersCount[c]), newCentroids[c])
 		}
-
 	}
 
-	return newCentroids
+	return km.assignments[currVector] != prevAssignment, computed
 }
 
-// updateBounds updates the lower and upper bounds for each vector.
-func (km *ElkanClusterer) updateBounds(newCentroid []*mat.VecDense) {
+// updateBounds updates the lower and upper bounds for each vector and returns the largest centroid shift.
+func (km *ElkanClusterer) updateBounds(newCentroid []*mat.VecDense) (maxShift float64) {
 
 	// compute the centroid shift distance matrix once.
 	// d(c', m(c')) in the paper
-	centroidShiftDist := make([]float64, km.clusterCnt)
-	var wg sync.WaitGroup
-	for c := 0; c < km.clusterCnt; c++ {
-		wg.Add(1)
-		go func(cIdx int) {
-			defer wg.Done()
-			centroidShiftDist[cIdx] = km.distFn(km.centroids[cIdx], newCentroid[cIdx])
-			//logutil.Debugf("centroidShiftDist[%d]=%f", cIdx, centroidShiftDist[cIdx])
-		}(c)
-	}
-	wg.Wait()
+	centroidShiftDist, maxShift := km.centroidShifts(newCentroid)
 
 	// step 5
 	//For each point x and center c, assign
 	// l(x, c)= max{ l(x, c)-d(c, m(c)), 0 }
-	for x := range km.vectorList {
-		for c := range km.centroids {
-			shift := km.vectorMetas[x].lower[c] - centroidShiftDist[c]
-			km.vectorMetas[x].lower[c] = math.Max(shift, 0)
-		}
-
-		// step 6
-		// For each point x, assign
-		// u(x)= u(x) + d(m(c(x)), c(x))
-		// r(x)= true
-		cx := km.assignments[x]
-		km.vectorMetas[x].upper += centroidShiftDist[cx]
-		km.vectorMetas[x].recompute = true
-	}
-}
-
-// isConverged checks if the algorithm has converged.
-func (km *ElkanClusterer) isConverged(iter int, changes int) bool {
-	if iter == km.maxIterations || changes == 0 {
-		return true
-	}
-	// NOTE: we are not using deltaThreshold right now.
-	//if changes < int(float64(km.vectorCnt)*km.deltaThreshold) {
-	//	return true
-	//}
-	return false
-}
+	parallelFor(km.vectorCnt, km.workers, func(start, end int) {
+		for x := start; x < end; x++ {
+			for c := range km.centroids {
+				shift := km.vectorMetas[x].lower[c] - centroidShiftDist[c]
+				km.vectorMetas[x].lower[c] = math.Max(shift, 0)
+			}
 
-// SSE returns the sum of squared errors.
-func (km *ElkanClusterer) SSE() float64 {
-	sse := 0.0
-	for i := range km.vectorList {
-		distErr := km.distFn(km.vectorList[i], km.centroids[km.assignments[i]])
-		sse += math.Pow(distErr, 2)
-	}
-	return sse
+			// step 6
+			// For each point x, assign
+			// u(x)= u(x) + d(m(c(x)), c(x))
+			// r(x)= true
+			cx := km.assignments[x]
+			km.vectorMetas[x].upper += centroidShiftDist[cx]
+			km.vectorMetas[x].recompute = true
+		}
+	})
+	return maxShift
 }
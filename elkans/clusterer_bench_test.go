@@ -72,6 +72,47 @@ func Benchmark_kmeans(b *testing.B) {
 		b.Log("SSE - clusterRand", strconv.FormatFloat(kmeansPlusPlus.SSE(), 'f', -1, 32))
 	})
 
+	b.Run("Spherical_Elkan_Kmeans||", func(b *testing.B) {
+		b.ResetTimer()
+		kmeansParallel, _ := NewElkanClusterer(data, k,
+			WithInit(kmeans.KmeansParallel), WithNormalize(true))
+		_, err := kmeansParallel.Cluster()
+		if err != nil {
+			panic(err)
+		}
+		b.Log("SSE - kmeansParallel", strconv.FormatFloat(kmeansParallel.SSE(), 'f', -1, 32))
+	})
+
+	b.Run("Spherical_Hamerly_Kmeans++", func(b *testing.B) {
+		b.ResetTimer()
+		hamerly, _ := NewHamerlyClusterer(data, k, WithNormalize(true))
+		_, err := hamerly.Cluster()
+		if err != nil {
+			panic(err)
+		}
+		b.Log("SSE - hamerly", strconv.FormatFloat(hamerly.SSE(), 'f', -1, 32))
+	})
+
+	b.Run("Spherical_Yinyang_Kmeans++", func(b *testing.B) {
+		b.ResetTimer()
+		yinyang, _ := NewYinyangClusterer(data, k, WithNormalize(true))
+		_, err := yinyang.Cluster()
+		if err != nil {
+			panic(err)
+		}
+		b.Log("SSE - yinyang", strconv.FormatFloat(yinyang.SSE(), 'f', -1, 32))
+	})
+
+	b.Run("Spherical_MiniBatch_Kmeans++", func(b *testing.B) {
+		b.ResetTimer()
+		miniBatch, _ := NewMiniBatchClusterer(data, k, WithNormalize(true))
+		_, err := miniBatch.Cluster()
+		if err != nil {
+			panic(err)
+		}
+		b.Log("SSE - miniBatch", strconv.FormatFloat(miniBatch.SSE(), 'f', -1, 32))
+	})
+
 }
 
 func populateRandData(rowCnt int, dim int, vecs [][]float64) {
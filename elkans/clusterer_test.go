@@ -15,10 +15,15 @@
 package elkans
 
 import (
+	"context"
+	"errors"
 	"github.com/arjunsk/kmeans"
 	"github.com/arjunsk/kmeans/utils/assertx"
 	"github.com/arjunsk/kmeans/utils/moarray"
+	"gonum.org/v1/gonum/mat"
+	"math/rand"
 	"reflect"
+	"sync/atomic"
 	"testing"
 )
 
@@ -140,8 +145,8 @@ func Test_Cluster(t *testing.T) {
 			want: [][]float64{
 				//{0.15915269938161652, 0.31830539876323305, 0.5757527355814478, 0.7349054349630643}, // approx {1, 2, 3.6666666666666665, 4.666666666666666}
 				//{0.8077006350571528, 0.26637173227965466, 0.3230802540228611, 0.4038503175285764},  // approx {10, 3.333333333333333, 4, 5}
-				{10, 3.333333333333333, 4, 5},
 				{1, 2, 3.6666666666666665, 4.666666666666666},
+				{10, 3.333333333333333, 4, 5},
 			},
 			//wantSSE: 0.0657884123589134,
 			wantSSE: 12,
@@ -170,6 +175,425 @@ func Test_Cluster(t *testing.T) {
 	}
 }
 
+func TestElkanClusterer_Result(t *testing.T) {
+	type constructorArgs struct {
+		vectorList     [][]float64
+		clusterCnt     int
+		maxIterations  int
+		deltaThreshold float64
+		distType       kmeans.DistanceType
+		initType       kmeans.InitType
+	}
+	tests := []struct {
+		name   string
+		fields constructorArgs
+		want   kmeans.ClusterResult
+	}{
+		{
+			name: "Test 1 - Skewed data (Random Init)",
+			fields: constructorArgs{
+				vectorList: [][]float64{
+					{1, 2, 3, 4},
+					{1, 2, 4, 5},
+					{1, 2, 4, 5},
+					{1, 2, 3, 4},
+					{1, 2, 4, 5},
+					{1, 2, 4, 5},
+					{10, 2, 4, 5},
+					{10, 3, 4, 5},
+					{10, 5, 4, 5},
+					{10, 2, 4, 5},
+					{10, 3, 4, 5},
+					{10, 5, 4, 5},
+				},
+				clusterCnt:     2,
+				maxIterations:  500,
+				deltaThreshold: 0.01,
+				distType:       kmeans.L2Distance,
+				initType:       kmeans.Random,
+			},
+			want: kmeans.ClusterResult{
+				Centroids: [][]float64{
+					{1, 2, 3.6666666666666665, 4.666666666666666},
+					{10, 3.333333333333333, 4, 5},
+				},
+				Labels:            []int{0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1},
+				ClusterSizes:      []int64{6, 6},
+				ClusterSSE:        []float64{2.6666666666666665, 9.333333333333334},
+				SSE:               12,
+				ConvergenceReason: kmeans.NoReassignments,
+			},
+		},
+		{
+			name: "Test 2 - Cluster count equals vector count",
+			fields: constructorArgs{
+				vectorList: [][]float64{
+					{1, 2, 3, 4},
+					{10, 2, 4, 5},
+				},
+				clusterCnt:     2,
+				maxIterations:  500,
+				deltaThreshold: 0.01,
+				distType:       kmeans.L2Distance,
+				initType:       kmeans.Random,
+			},
+			want: kmeans.ClusterResult{
+				Centroids: [][]float64{
+					{1, 2, 3, 4},
+					{10, 2, 4, 5},
+				},
+				Labels:            []int{0, 1},
+				ClusterSizes:      []int64{1, 1},
+				ClusterSSE:        []float64{0, 0},
+				SSE:               0,
+				ConvergenceReason: kmeans.NoReassignments,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, err := NewKMeans(tt.fields.vectorList, tt.fields.clusterCnt,
+				tt.fields.maxIterations, tt.fields.deltaThreshold,
+				tt.fields.distType, tt.fields.initType, false)
+			if err != nil {
+				t.Errorf("Error while creating KMeans object %v", err)
+			}
+			ekm, ok := km.(*ElkanClusterer)
+			if !ok {
+				t.Errorf("km not of type ElkanClusterer")
+				return
+			}
+			if ekm.Result() != nil {
+				t.Errorf("Result() should be nil before Cluster()")
+			}
+			if _, err = ekm.Cluster(); err != nil {
+				t.Errorf("Cluster() error = %v", err)
+				return
+			}
+
+			got := ekm.Result()
+			if !assertx.InEpsilonF64Slices(tt.want.Centroids, got.Centroids) {
+				t.Errorf("Centroids got = %v, want %v", got.Centroids, tt.want.Centroids)
+			}
+			if !reflect.DeepEqual(tt.want.Labels, got.Labels) {
+				t.Errorf("Labels got = %v, want %v", got.Labels, tt.want.Labels)
+			}
+			if !reflect.DeepEqual(tt.want.ClusterSizes, got.ClusterSizes) {
+				t.Errorf("ClusterSizes got = %v, want %v", got.ClusterSizes, tt.want.ClusterSizes)
+			}
+			if !assertx.InEpsilonF64Slice(tt.want.ClusterSSE, got.ClusterSSE) {
+				t.Errorf("ClusterSSE got = %v, want %v", got.ClusterSSE, tt.want.ClusterSSE)
+			}
+			if !assertx.InEpsilonF64(tt.want.SSE, got.SSE) || !assertx.InEpsilonF64(ekm.SSE(), got.SSE) {
+				t.Errorf("SSE got = %v, want %v", got.SSE, tt.want.SSE)
+			}
+			if got.ConvergenceReason != tt.want.ConvergenceReason {
+				t.Errorf("ConvergenceReason got = %v, want %v", got.ConvergenceReason, tt.want.ConvergenceReason)
+			}
+			if got.Iterations < 0 || got.Iterations > tt.fields.maxIterations+1 {
+				t.Errorf("Iterations got = %v, out of bounds", got.Iterations)
+			}
+		})
+	}
+}
+
+func TestElkanClusterer_ClusterContext(t *testing.T) {
+	data := make([][]float64, 200)
+	populateRandData(200, 4, data)
+
+	tests := []struct {
+		name          string
+		cancelAfter   int // number of distance computations after which the context is cancelled
+		wantCentroids bool
+	}{
+		{
+			name:          "Test 1 - Cancelled before clustering",
+			cancelAfter:   0,
+			wantCentroids: false,
+		},
+		{
+			name:          "Test 2 - Cancelled while clustering",
+			cancelAfter:   3000,
+			wantCentroids: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, err := NewKMeans(data, 5, 500, 0.01, kmeans.L2Distance, kmeans.KmeansPlusPlus, false)
+			if err != nil {
+				t.Fatalf("Error while creating KMeans object %v", err)
+			}
+			ekm := km.(*ElkanClusterer)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelAfter == 0 {
+				cancel()
+			}
+			var distCnt int64
+			ekm.distFn = func(v1, v2 *mat.VecDense) float64 {
+				if atomic.AddInt64(&distCnt, 1) == int64(tt.cancelAfter) {
+					cancel()
+				}
+				return L2Distance(v1, v2)
+			}
+
+			got, err := ekm.ClusterContext(ctx)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("ClusterContext() error = %v, want %v", err, context.Canceled)
+			}
+			if (got != nil) != tt.wantCentroids {
+				t.Errorf("ClusterContext() got = %v, wantCentroids %v", got, tt.wantCentroids)
+			}
+			if tt.wantCentroids {
+				if len(got) != 5 {
+					t.Errorf("ClusterContext() got %v centroids, want %v", len(got), 5)
+				}
+				if reason := ekm.Result().ConvergenceReason; reason != kmeans.Cancelled {
+					t.Errorf("ConvergenceReason got = %v, want %v", reason, kmeans.Cancelled)
+				}
+			}
+		})
+	}
+}
+
+func TestNewElkanClusterer(t *testing.T) {
+	vectorList := [][]float64{{1}, {3}, {10}, {12}}
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr bool
+	}{
+		{
+			name: "Test 1 - Defaults",
+			opts: nil,
+		},
+		{
+			name: "Test 2 - All options",
+			opts: []Option{
+				WithMaxIter(10),
+				WithTolerance(0.1),
+				WithConvergence(kmeans.CentroidShift),
+				WithDistance(kmeans.L2Distance),
+				WithInit(kmeans.RandomPartition),
+				WithNormalize(false),
+				WithSeed(42),
+				WithWorkers(2),
+				WithEmptyClusterPolicy(kmeans.EmptyClusterRandomVector),
+				WithRestarts(2),
+				WithConcurrentRestarts(true),
+			},
+		},
+		{
+			name:    "Test 3 - Invalid max iterations",
+			opts:    []Option{WithMaxIter(-1)},
+			wantErr: true,
+		},
+		{
+			name:    "Test 4.a - Invalid tolerance",
+			opts:    []Option{WithTolerance(1)},
+			wantErr: true,
+		},
+		{
+			name: "Test 4.b - Centroid shift tolerance above 1",
+			opts: []Option{WithTolerance(5), WithConvergence(kmeans.CentroidShift)},
+		},
+		{
+			name:    "Test 4.c - Invalid centroid shift tolerance",
+			opts:    []Option{WithTolerance(0), WithConvergence(kmeans.CentroidShift)},
+			wantErr: true,
+		},
+		{
+			name:    "Test 5 - Invalid convergence type",
+			opts:    []Option{WithConvergence(kmeans.ConvergenceType(10))},
+			wantErr: true,
+		},
+		{
+			name:    "Test 6 - Invalid distance type",
+			opts:    []Option{WithDistance(kmeans.DistanceType(10))},
+			wantErr: true,
+		},
+		{
+			name:    "Test 7 - Invalid init type",
+			opts:    []Option{WithInit(kmeans.InitType(10))},
+			wantErr: true,
+		},
+		{
+			name:    "Test 8 - Invalid workers",
+			opts:    []Option{WithWorkers(-1)},
+			wantErr: true,
+		},
+		{
+			name:    "Test 9 - Invalid empty cluster policy",
+			opts:    []Option{WithEmptyClusterPolicy(kmeans.EmptyClusterPolicy(10))},
+			wantErr: true,
+		},
+		{
+			name:    "Test 10.a - Invalid init rounds",
+			opts:    []Option{WithKMeansParallelConfig(0, 2)},
+			wantErr: true,
+		},
+		{
+			name:    "Test 10.b - Invalid init oversampling",
+			opts:    []Option{WithKMeansParallelConfig(5, 0)},
+			wantErr: true,
+		},
+		{
+			name:    "Test 11 - Invalid restarts",
+			opts:    []Option{WithRestarts(0)},
+			wantErr: true,
+		},
+		{
+			name:    "Test 12.a - Initial centroids count mismatch",
+			opts:    []Option{WithInitialCentroids([][]float64{{1}})},
+			wantErr: true,
+		},
+		{
+			name:    "Test 12.b - Initial centroids dimension mismatch",
+			opts:    []Option{WithInitialCentroids([][]float64{{1}, {2, 3}})},
+			wantErr: true,
+		},
+		{
+			name:    "Test 12.c - Initial centroids with restarts",
+			opts:    []Option{WithInitialCentroids([][]float64{{1}, {2}}), WithRestarts(2)},
+			wantErr: true,
+		},
+		{
+			name: "Test 12.d - Initial centroids",
+			opts: []Option{WithInitialCentroids([][]float64{{1}, {2}})},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewElkanClusterer(vectorList, 2, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewElkanClusterer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestElkanClusterer_Cluster_ConvergenceTypes(t *testing.T) {
+	for _, convergenceType := range []kmeans.ConvergenceType{kmeans.ReassignmentRatio, kmeans.CentroidShift, kmeans.SSEImprovement} {
+		for _, workers := range []int{1, 3} {
+			ekm, err := NewElkanClusterer([][]float64{{1}, {3}, {10}, {12}}, 2,
+				WithConvergence(convergenceType), WithWorkers(workers))
+			if err != nil {
+				t.Fatalf("Error while creating KMeans object %v", err)
+			}
+			if _, err = ekm.Cluster(); err != nil {
+				t.Errorf("Cluster() error = %v", err)
+			}
+			if !assertx.InEpsilonF64(4, ekm.SSE()) {
+				t.Errorf("SSE() got = %v, want %v", ekm.SSE(), 4)
+			}
+			if reason := ekm.Result().ConvergenceReason; reason == kmeans.NotConverged {
+				t.Errorf("ConvergenceReason got = %v", reason)
+			}
+		}
+	}
+}
+
+func TestElkanClusterer_InitialCentroids(t *testing.T) {
+	vectorList := [][]float64{
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+	}
+	tests := []struct {
+		name             string
+		initialCentroids [][]float64
+		want             [][]float64
+		wantMaxIter      int
+	}{
+		{
+			name:             "Test 1 - Warm start from the final centroids",
+			initialCentroids: [][]float64{{10, 3.333333333333333, 4, 5}, {1, 2, 3.6666666666666665, 4.666666666666666}},
+			want:             [][]float64{{10, 3.333333333333333, 4, 5}, {1, 2, 3.6666666666666665, 4.666666666666666}},
+			wantMaxIter:      2,
+		},
+		{
+			name:             "Test 2 - Start from arbitrary centroids",
+			initialCentroids: [][]float64{{20, 0, 0, 0}, {0, 0, 0, 0}},
+			want:             [][]float64{{10, 3.333333333333333, 4, 5}, {1, 2, 3.6666666666666665, 4.666666666666666}},
+			wantMaxIter:      kmeans.DefaultMaxIterations + 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ekm, err := NewElkanClusterer(vectorList, 2, WithInitialCentroids(tt.initialCentroids))
+			if err != nil {
+				t.Fatalf("Error while creating KMeans object %v", err)
+			}
+			got, err := ekm.Cluster()
+			if err != nil {
+				t.Fatalf("Cluster() error = %v", err)
+			}
+			if !assertx.InEpsilonF64Slices(tt.want, got) {
+				t.Errorf("Cluster() got = %v, want %v", got, tt.want)
+			}
+			if iterations := ekm.Result().Iterations; iterations > tt.wantMaxIter {
+				t.Errorf("Iterations got = %v, want <= %v", iterations, tt.wantMaxIter)
+			}
+		})
+	}
+}
+
+func TestElkanClusterer_Reproducibility(t *testing.T) {
+	data := make([][]float64, 500)
+	populateRandData(500, 8, data)
+
+	cluster := func(opts ...Option) ([][]float64, []int) {
+		ekm, err := NewElkanClusterer(data, 10, opts...)
+		if err != nil {
+			t.Fatalf("Error while creating KMeans object %v", err)
+		}
+		centroids, err := ekm.Cluster()
+		if err != nil {
+			t.Fatalf("Cluster() error = %v", err)
+		}
+		return centroids, ekm.Result().Labels
+	}
+
+	for _, initType := range []kmeans.InitType{kmeans.Random, kmeans.KmeansPlusPlus} {
+		for _, seed := range []int64{1, 7} {
+			wantCentroids, wantLabels := cluster(WithInit(initType), WithSeed(seed), WithWorkers(1))
+
+			for _, workers := range []int{0, 2, 5} {
+				gotCentroids, gotLabels := cluster(WithInit(initType), WithSeed(seed), WithWorkers(workers))
+				if !reflect.DeepEqual(wantCentroids, gotCentroids) || !reflect.DeepEqual(wantLabels, gotLabels) {
+					t.Errorf("initType=%v seed=%v workers=%v: results differ from single worker run", initType, seed, workers)
+				}
+			}
+
+			gotCentroids, gotLabels := cluster(WithInit(initType), WithRandSource(rand.NewSource(seed)))
+			if !reflect.DeepEqual(wantCentroids, gotCentroids) || !reflect.DeepEqual(wantLabels, gotLabels) {
+				t.Errorf("initType=%v seed=%v: WithRandSource results differ from WithSeed", initType, seed)
+			}
+		}
+	}
+
+	// repeated Cluster calls on the same clusterer restart from the seed.
+	ekm, err := NewElkanClusterer(data, 10, WithInit(kmeans.Random), WithSeed(3))
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	first, _ := ekm.Cluster()
+	second, _ := ekm.Cluster()
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Cluster() results differ between calls with the same seed")
+	}
+}
+
 func TestElkanClusterer_initBounds(t *testing.T) {
 	type constructorArgs struct {
 		vectorList     [][]float64
@@ -259,7 +683,7 @@ func TestElkanClusterer_initBounds(t *testing.T) {
 			}
 			if ekm, ok := km.(*ElkanClusterer); ok {
 				ekm.centroids, _ = moarray.ToGonumVectors[float64](tt.state.centroids...)
-				ekm.initBounds()
+				_ = ekm.initBounds(context.Background())
 				if !reflect.DeepEqual(ekm.assignments, tt.want.assignment) {
 					t.Errorf("assignments got = %v, want %v", ekm.assignments, tt.want.assignment)
 				}
@@ -593,3 +1017,96 @@ func TestElkanClusterer_updateBounds(t *testing.T) {
 		})
 	}
 }
+
+func TestElkanClusterer_isConverged(t *testing.T) {
+	type internalState struct {
+		convergenceType kmeans.ConvergenceType
+		deltaThreshold  float64
+		maxIterations   int
+		prevSSE         float64
+	}
+	type args struct {
+		iter     int
+		changes  int
+		maxShift float64
+	}
+	type wantState struct {
+		converged bool
+		reason    kmeans.ConvergenceReason
+	}
+	tests := []struct {
+		name  string
+		state internalState
+		args  args
+		want  wantState
+	}{
+		{
+			name:  "Test 1 - First iteration",
+			state: internalState{convergenceType: kmeans.ReassignmentRatio, deltaThreshold: 0.3, maxIterations: 10},
+			args:  args{iter: 0, changes: 0},
+			want:  wantState{converged: false, reason: kmeans.NotConverged},
+		},
+		{
+			name:  "Test 2 - No reassignments",
+			state: internalState{convergenceType: kmeans.CentroidShift, deltaThreshold: 0.3, maxIterations: 10},
+			args:  args{iter: 1, changes: 0, maxShift: 5},
+			want:  wantState{converged: true, reason: kmeans.NoReassignments},
+		},
+		{
+			name:  "Test 3.a - Reassignment ratio below threshold",
+			state: internalState{convergenceType: kmeans.ReassignmentRatio, deltaThreshold: 0.3, maxIterations: 10},
+			args:  args{iter: 1, changes: 1},
+			want:  wantState{converged: true, reason: kmeans.ReassignmentRatioBelowThreshold},
+		},
+		{
+			name:  "Test 3.b - Reassignment ratio above threshold",
+			state: internalState{convergenceType: kmeans.ReassignmentRatio, deltaThreshold: 0.3, maxIterations: 10},
+			args:  args{iter: 1, changes: 2},
+			want:  wantState{converged: false, reason: kmeans.NotConverged},
+		},
+		{
+			name:  "Test 4 - Centroid shift below threshold",
+			state: internalState{convergenceType: kmeans.CentroidShift, deltaThreshold: 0.1, maxIterations: 10},
+			args:  args{iter: 1, changes: 3, maxShift: 0.05},
+			want:  wantState{converged: true, reason: kmeans.CentroidShiftBelowThreshold},
+		},
+		{
+			name:  "Test 5.a - SSE improvement below threshold",
+			state: internalState{convergenceType: kmeans.SSEImprovement, deltaThreshold: 0.1, maxIterations: 10, prevSSE: 4.1},
+			args:  args{iter: 1, changes: 3},
+			want:  wantState{converged: true, reason: kmeans.SSEImprovementBelowThreshold},
+		},
+		{
+			name:  "Test 5.b - SSE improvement above threshold and max iterations reached",
+			state: internalState{convergenceType: kmeans.SSEImprovement, deltaThreshold: 0.1, maxIterations: 10, prevSSE: 8},
+			args:  args{iter: 10, changes: 3},
+			want:  wantState{converged: true, reason: kmeans.MaxIterationsReached},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, err := NewKMeans([][]float64{{1}, {3}, {10}, {12}}, 2,
+				tt.state.maxIterations, tt.state.deltaThreshold,
+				kmeans.L2Distance, kmeans.Random, false)
+			if err != nil {
+				t.Errorf("Error while creating KMeans object %v", err)
+			}
+			if ekm, ok := km.(*ElkanClusterer); ok {
+				ekm.convergenceType = tt.state.convergenceType
+				// SSE of this state is 4.
+				ekm.centroids, _ = moarray.ToGonumVectors[float64]([]float64{2}, []float64{11})
+				ekm.assignments = []int{0, 0, 1, 1}
+				ekm.prevSSE = tt.state.prevSSE
+
+				if got := ekm.isConverged(tt.args.iter, tt.args.changes, tt.args.maxShift); got != tt.want.converged {
+					t.Errorf("isConverged() got = %v, want %v", got, tt.want.converged)
+				}
+				if ekm.convergenceReason != tt.want.reason {
+					t.Errorf("convergenceReason got = %v, want %v", ekm.convergenceReason, tt.want.reason)
+				}
+			} else if !ok {
+				t.Errorf("km not of type ElkanClusterer")
+			}
+		})
+	}
+}
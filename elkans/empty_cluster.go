@@ -0,0 +1,214 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	moarray2 "github.com/arjunsk/kmeans/utils/moarray"
+	"gonum.org/v1/gonum/mat"
+	"sort"
+)
+
+// emptyClusterSplitEpsilon is how far the two centroids of a split cluster are moved from the cluster mean,
+// as a fraction of the distance to the farthest member of the cluster.
+const emptyClusterSplitEpsilon = 1.0 / 1024
+
+// reseedEmptyClusters replaces the centroids of the empty clusters according to km.emptyClusterPolicy.
+// newCentroids holds the means of the non-empty clusters and membersCount the number of vectors of each cluster.
+// The assignments are not changed: the next iteration assigns the vectors to the new centroids.
+func (km *clusterer) reseedEmptyClusters(newCentroids []*mat.VecDense, membersCount []int64) {
+	var empty []int
+	for c, cnt := range membersCount {
+		if cnt == 0 {
+			empty = append(empty, c)
+		}
+	}
+	if len(empty) == 0 {
+		return
+	}
+
+	// only the replaced centroids are counted as reseeds: keeping the previous centroid is not.
+
+	switch km.emptyClusterPolicy {
+	case kmeans.EmptyClusterFarthestPoint:
+		km.reseedFarthestPoints(newCentroids, membersCount, empty)
+	case kmeans.EmptyClusterSplitLargest, kmeans.EmptyClusterSplitHighestSSE:
+		km.reseedBySplit(newCentroids, membersCount, empty)
+	case kmeans.EmptyClusterRandomPoint:
+		km.reseedRandomPoints(newCentroids, membersCount, empty)
+	case kmeans.EmptyClusterKeepPrevious:
+		for _, c := range empty {
+			newCentroids[c] = mat.VecDenseCopyOf(km.centroids[c])
+		}
+	default:
+		km.emptyClusterReseeds += len(empty)
+		for _, c := range empty {
+			// if the cluster is empty, reinitialize it to a random vector, since you can't find the mean of an empty set
+			randVector := make([]float64, km.vectorList[0].Len())
+			for l := range randVector {
+				randVector[l] = km.rand.Float64()
+			}
+			newCentroids[c] = mat.NewVecDense(km.vectorList[0].Len(), randVector)
+
+			// normalize the random vector
+			if km.normalize {
+				moarray2.NormalizeGonumVector(newCentroids[c])
+			}
+		}
+	}
+}
+
+// reseedFarthestPoints moves the vectors farthest from their centroid to the empty clusters, and removes them
+// from the means of their clusters. A vector is only moved if its cluster keeps other vectors and it does not
+// lie on its centroid; when no such vector is left, the previous centroid is kept.
+func (km *clusterer) reseedFarthestPoints(newCentroids []*mat.VecDense, membersCount []int64, empty []int) {
+	distances := km.distancesToCentroids(newCentroids)
+
+	// ties go to the lowest index, so that the choice does not depend on the number of workers.
+	order := make([]int, km.vectorCnt)
+	for x := range order {
+		order[x] = x
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return distances[order[i]] > distances[order[j]]
+	})
+
+	next := 0
+	for _, c := range empty {
+		for next < len(order) && distances[order[next]] > 0 && membersCount[km.assignments[order[next]]] <= 1 {
+			next++
+		}
+		if next == len(order) || distances[order[next]] == 0 {
+			newCentroids[c] = mat.VecDenseCopyOf(km.centroids[c])
+			continue
+		}
+
+		x := order[next]
+		next++
+		donor := km.assignments[x]
+		newCentroids[donor].ScaleVec(float64(membersCount[donor]), newCentroids[donor])
+		newCentroids[donor].SubVec(newCentroids[donor], km.vectorList[x])
+		membersCount[donor]--
+		newCentroids[donor].ScaleVec(1/float64(membersCount[donor]), newCentroids[donor])
+
+		newCentroids[c] = mat.VecDenseCopyOf(km.vectorList[x])
+		membersCount[c] = 1
+		km.emptyClusterReseeds++
+	}
+}
+
+// reseedRandomPoints replaces the centroids of the empty clusters with distinct vectors drawn without
+// replacement, skipping the vectors equal to a centroid, so that no two centroids are tied. When no such
+// vector is left, the previous centroid is kept.
+func (km *clusterer) reseedRandomPoints(newCentroids []*mat.VecDense, membersCount []int64, empty []int) {
+	chosen := make(map[uint64][]*mat.VecDense)
+	for c, cnt := range membersCount {
+		if cnt > 0 {
+			hash := hashVector(newCentroids[c])
+			chosen[hash] = append(chosen[hash], newCentroids[c])
+		}
+	}
+
+	// a partial Fisher-Yates shuffle draws the vectors in a random order, without replacement.
+	indices := make([]int, km.vectorCnt)
+	for i := range indices {
+		indices[i] = i
+	}
+	next := 0
+	for _, c := range empty {
+		newCentroids[c] = nil
+		for ; next < km.vectorCnt && newCentroids[c] == nil; next++ {
+			j := next + km.rand.Intn(km.vectorCnt-next)
+			indices[next], indices[j] = indices[j], indices[next]
+
+			vec := km.vectorList[indices[next]]
+			hash := hashVector(vec)
+			if containsVector(chosen[hash], vec) {
+				continue
+			}
+			chosen[hash] = append(chosen[hash], vec)
+			newCentroids[c] = mat.VecDenseCopyOf(vec)
+			km.emptyClusterReseeds++
+		}
+		if newCentroids[c] == nil {
+			newCentroids[c] = mat.VecDenseCopyOf(km.centroids[c])
+		}
+	}
+}
+
+// reseedBySplit splits the cluster with the most vectors (EmptyClusterSplitLargest) or the largest SSE
+// (EmptyClusterSplitHighestSSE) for each empty cluster. Both centroids are moved slightly away from the mean,
+// along the direction of the farthest member, so that the next assignment divides the cluster in two.
+// Clusters whose vectors all lie on their centroid cannot be split; when no other cluster is left, the
+// previous centroid is kept.
+func (km *clusterer) reseedBySplit(newCentroids []*mat.VecDense, membersCount []int64, empty []int) {
+	distances := km.distancesToCentroids(newCentroids)
+
+	weights := make([]float64, km.clusterCnt)
+	farthest := make([]int, km.clusterCnt)
+	for c := range farthest {
+		farthest[c] = -1
+	}
+	for x, d := range distances {
+		cx := km.assignments[x]
+		if km.emptyClusterPolicy == kmeans.EmptyClusterSplitHighestSSE {
+			weights[cx] += d * d
+		} else {
+			weights[cx] = float64(membersCount[cx])
+		}
+		if d > 0 && (farthest[cx] == -1 || d > distances[farthest[cx]]) {
+			farthest[cx] = x
+		}
+	}
+
+	for _, c := range empty {
+		split := -1
+		for d := range weights {
+			if membersCount[d] > 1 && farthest[d] != -1 && (split == -1 || weights[d] > weights[split]) {
+				split = d
+			}
+		}
+		if split == -1 {
+			newCentroids[c] = mat.VecDenseCopyOf(km.centroids[c])
+			continue
+		}
+
+		direction := mat.NewVecDense(newCentroids[split].Len(), nil)
+		direction.SubVec(km.vectorList[farthest[split]], newCentroids[split])
+		newCentroids[c] = mat.VecDenseCopyOf(newCentroids[split])
+		newCentroids[c].AddScaledVec(newCentroids[c], emptyClusterSplitEpsilon, direction)
+		newCentroids[split].AddScaledVec(newCentroids[split], -emptyClusterSplitEpsilon, direction)
+		km.emptyClusterReseeds++
+
+		// each half is expected to get half of the vectors. The farthest member now belongs to the new
+		// cluster, so neither is split again by this call.
+		membersCount[c] = membersCount[split] / 2
+		membersCount[split] -= membersCount[c]
+		weights[split] /= 2
+		weights[c] = weights[split]
+		farthest[split] = -1
+	}
+}
+
+// distancesToCentroids returns the distance of every vector to the centroid of its cluster.
+func (km *clusterer) distancesToCentroids(centroids []*mat.VecDense) []float64 {
+	distances := make([]float64, km.vectorCnt)
+	parallelFor(km.vectorCnt, km.workers, func(start, end int) {
+		for x := start; x < end; x++ {
+			distances[x] = km.distFn(km.vectorList[x], centroids[km.assignments[x]])
+		}
+	})
+	return distances
+}
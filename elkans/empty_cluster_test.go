@@ -0,0 +1,193 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"github.com/arjunsk/kmeans/utils/moarray"
+	"testing"
+)
+
+func TestElkanClusterer_reseedEmptyClusters(t *testing.T) {
+	const eps = emptyClusterSplitEpsilon
+	// cluster 2 is empty. The means are {1} and {12}, and the previous centroids {0}, {5} and {100}.
+	vectorList := [][]float64{{0}, {1}, {2}, {10}, {14}}
+	assignments := []int{0, 0, 0, 1, 1}
+	previous := [][]float64{{0}, {5}, {100}}
+
+	tests := []struct {
+		name        string
+		policy      kmeans.EmptyClusterPolicy
+		want        [][]float64
+		wantReseeds int
+	}{
+		{
+			name:   "Test 1 - farthest point",
+			policy: kmeans.EmptyClusterFarthestPoint,
+			// {10} is the farthest from its centroid, and is moved out of cluster 1.
+			want:        [][]float64{{1}, {14}, {10}},
+			wantReseeds: 1,
+		},
+		{
+			name:   "Test 2 - split largest",
+			policy: kmeans.EmptyClusterSplitLargest,
+			// cluster 0 has 3 vectors, and its farthest member is {0}.
+			want:        [][]float64{{1 + eps}, {12}, {1 - eps}},
+			wantReseeds: 1,
+		},
+		{
+			name:   "Test 3 - split highest SSE",
+			policy: kmeans.EmptyClusterSplitHighestSSE,
+			// cluster 1 has an SSE of 8, and its farthest member is {10}.
+			want:        [][]float64{{1}, {12 + 2*eps}, {12 - 2*eps}},
+			wantReseeds: 1,
+		},
+		{
+			name:   "Test 4 - keep previous",
+			policy: kmeans.EmptyClusterKeepPrevious,
+			want:   [][]float64{{1}, {12}, {100}},
+			// the previous centroid is kept, so nothing is reseeded.
+			wantReseeds: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ekm, err := NewElkanClusterer(vectorList, 3, WithEmptyClusterPolicy(tt.policy))
+			if err != nil {
+				t.Fatalf("Error while creating KMeans object %v", err)
+			}
+			ekm.assignments = assignments
+			ekm.centroids, _ = moarray.ToGonumVectors[float64](previous...)
+
+			got := moarray.ToMoArrays[float64](ekm.recalculateCentroids())
+			if !assertx.InEpsilonF64Slices(tt.want, got) {
+				t.Errorf("centroids got = %v, want %v", got, tt.want)
+			}
+			if ekm.emptyClusterReseeds != tt.wantReseeds {
+				t.Errorf("emptyClusterReseeds got = %v, want %v", ekm.emptyClusterReseeds, tt.wantReseeds)
+			}
+		})
+	}
+}
+
+func TestElkanClusterer_reseedEmptyClusters_Random(t *testing.T) {
+	vectorList := [][]float64{{0}, {1}, {2}, {10}, {14}}
+	for _, policy := range []kmeans.EmptyClusterPolicy{kmeans.EmptyClusterRandomVector, kmeans.EmptyClusterRandomPoint} {
+		ekm, err := NewElkanClusterer(vectorList, 3, WithEmptyClusterPolicy(policy))
+		if err != nil {
+			t.Fatalf("Error while creating KMeans object %v", err)
+		}
+		ekm.assignments = []int{0, 0, 0, 1, 1}
+
+		got := ekm.recalculateCentroids()[2].AtVec(0)
+		switch policy {
+		case kmeans.EmptyClusterRandomVector:
+			if got < 0 || got >= 1 {
+				t.Errorf("policy=%v: centroid got = %v, want a value in [0, 1)", policy, got)
+			}
+		case kmeans.EmptyClusterRandomPoint:
+			if got != 0 && got != 1 && got != 2 && got != 10 && got != 14 {
+				t.Errorf("policy=%v: centroid got = %v, want one of the vectors", policy, got)
+			}
+		}
+	}
+}
+
+func TestElkanClusterer_reseedEmptyClusters_RandomPointDistinct(t *testing.T) {
+	// clusters 2 and 3 are empty. The means are {0} and {8}, so only {7} and {9} are not centroids yet.
+	vectorList := [][]float64{{0}, {0}, {0}, {0}, {7}, {9}}
+	for seed := int64(0); seed < 20; seed++ {
+		ekm, err := NewElkanClusterer(vectorList, 4,
+			WithEmptyClusterPolicy(kmeans.EmptyClusterRandomPoint), WithSeed(seed))
+		if err != nil {
+			t.Fatalf("Error while creating KMeans object %v", err)
+		}
+		ekm.assignments = []int{0, 0, 0, 0, 1, 1}
+
+		got := moarray.ToMoArrays[float64](ekm.recalculateCentroids())
+		if !assertx.InEpsilonF64Slices([][]float64{{0}, {8}, {7}, {9}}, got) &&
+			!assertx.InEpsilonF64Slices([][]float64{{0}, {8}, {9}, {7}}, got) {
+			t.Errorf("seed=%v: centroids got = %v, want {7} and {9} for the empty clusters", seed, got)
+		}
+	}
+}
+
+func TestElkanClusterer_reseedEmptyClusters_NoDonor(t *testing.T) {
+	// the non-empty clusters only have identical vectors, so there is nothing to split or move.
+	vectorList := [][]float64{{1}, {1}, {5}, {5}}
+	for _, policy := range []kmeans.EmptyClusterPolicy{kmeans.EmptyClusterFarthestPoint,
+		kmeans.EmptyClusterSplitLargest, kmeans.EmptyClusterSplitHighestSSE} {
+		ekm, err := NewElkanClusterer(vectorList, 3, WithEmptyClusterPolicy(policy))
+		if err != nil {
+			t.Fatalf("Error while creating KMeans object %v", err)
+		}
+		ekm.assignments = []int{0, 0, 1, 1}
+		ekm.centroids, _ = moarray.ToGonumVectors[float64]([]float64{1}, []float64{5}, []float64{100})
+
+		want := [][]float64{{1}, {5}, {100}}
+		if got := moarray.ToMoArrays[float64](ekm.recalculateCentroids()); !assertx.InEpsilonF64Slices(want, got) {
+			t.Errorf("policy=%v: centroids got = %v, want %v", policy, got, want)
+		}
+		if ekm.emptyClusterReseeds != 0 {
+			t.Errorf("policy=%v: emptyClusterReseeds got = %v, want 0", policy, ekm.emptyClusterReseeds)
+		}
+	}
+}
+
+func TestElkanClusterer_Cluster_EmptyClusterPolicies(t *testing.T) {
+	// the third initial centroid is far from all the vectors, so its cluster is empty after the first assignment.
+	vectorList := [][]float64{{1}, {3}, {10}, {12}}
+	initialCentroids := [][]float64{{2}, {11}, {1000}}
+
+	for policy := kmeans.EmptyClusterRandomVector; policy < kmeans.EmptyClusterKeepPrevious; policy++ {
+		ekm, err := NewElkanClusterer(vectorList, 3,
+			WithInitialCentroids(initialCentroids), WithEmptyClusterPolicy(policy))
+		if err != nil {
+			t.Fatalf("Error while creating KMeans object %v", err)
+		}
+		if _, err = ekm.Cluster(); err != nil {
+			t.Fatalf("policy=%v: Cluster() error = %v", policy, err)
+		}
+		result := ekm.Result()
+		if result.EmptyClusterReseeds < 1 {
+			t.Errorf("policy=%v: EmptyClusterReseeds got = %v, want >= 1", policy, result.EmptyClusterReseeds)
+		}
+		if result.SSE > 4 {
+			t.Errorf("policy=%v: SSE got = %v, want <= 4", policy, result.SSE)
+		}
+	}
+}
+
+func TestElkanClusterer_Cluster_EmptyClusterKeepPrevious(t *testing.T) {
+	vectorList := [][]float64{{1}, {3}, {10}, {12}}
+	initialCentroids := [][]float64{{2}, {11}, {1000}}
+
+	ekm, err := NewElkanClusterer(vectorList, 3,
+		WithInitialCentroids(initialCentroids), WithEmptyClusterPolicy(kmeans.EmptyClusterKeepPrevious))
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	got, err := ekm.Cluster()
+	if err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+	if want := [][]float64{{2}, {11}, {1000}}; !assertx.InEpsilonF64Slices(want, got) {
+		t.Errorf("Cluster() got = %v, want %v", got, want)
+	}
+	if result := ekm.Result(); result.EmptyClusterReseeds != 0 {
+		t.Errorf("EmptyClusterReseeds got = %v, want 0", result.EmptyClusterReseeds)
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"context"
+	"github.com/arjunsk/kmeans"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// HamerlyClusterer is Hamerly's kmeans algorithm. Like ElkanClusterer, it uses the triangle inequality to skip
+// distance calculations, but it keeps a single lower bound per vector: the distance to its second-closest
+// centroid. It needs O(n+k) memory for the bounds instead of O(n*k), and the bound updates take O(n) time
+// per iteration instead of O(n*k). When the bounds fail, all the k distances of the vector are computed, so
+// it skips fewer distance calculations than Elkan, which matters for high dimensional vectors.
+//
+// Ref Paper: https://epubs.siam.org/doi/10.1137/1.9781611972801.12
+type HamerlyClusterer struct {
+	clusterer
+
+	// for each of the n vectors, we keep track of the following data
+	upper []float64 // u(x): at-most distance of x to its assigned centroid.
+	lower []float64 // l(x): at-least distance of x to any other centroid.
+
+	// for each of the k centroids, we keep track of the following data
+	minHalfInterCentroidDist []float64 // s(c) = 0.5 x min{d(c, c') | c' != c}
+}
+
+var _ kmeans.Clusterer = new(HamerlyClusterer)
+
+// NewHamerlyClusterer returns a HamerlyClusterer for clustering vectors into clusterCnt clusters.
+// The defaults can be overridden using the With* options.
+func NewHamerlyClusterer(vectors [][]float64, clusterCnt int, opts ...Option) (*HamerlyClusterer, error) {
+	base, err := newClusterer(vectors, clusterCnt, newOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	km := &HamerlyClusterer{
+		clusterer:                base,
+		upper:                    make([]float64, len(vectors)),
+		lower:                    make([]float64, len(vectors)),
+		minHalfInterCentroidDist: make([]float64, clusterCnt),
+	}
+	km.algo = km
+	return km, nil
+}
+
+func (km *HamerlyClusterer) base() *clusterer {
+	return &km.clusterer
+}
+
+// newRun returns a single-run copy of km, seeded with seed and with its own bounds and centroids.
+func (km *HamerlyClusterer) newRun(seed int64) algorithm {
+	run := &HamerlyClusterer{
+		clusterer:                km.clusterer.newRun(seed),
+		upper:                    make([]float64, km.vectorCnt),
+		lower:                    make([]float64, km.vectorCnt),
+		minHalfInterCentroidDist: make([]float64, km.clusterCnt),
+	}
+	run.algo = run
+	return run
+}
+
+// iterate runs Hamerly's kmeans from the initial centroids.
+func (km *HamerlyClusterer) iterate(ctx context.Context) error {
+	if err := km.initBounds(ctx); err != nil {
+		return err
+	}
+	if km.skipIterations() {
+		return nil
+	}
+
+	for iter := 0; ; iter++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		iterStart := km.startIteration()
+
+		km.computeCentroidDistances()
+
+		changes, err := km.assignData(ctx)
+		if err != nil {
+			return err
+		}
+
+		newCentroids := km.recalculateCentroids()
+
+		maxShift := km.updateBounds(newCentroids)
+
+		km.centroids = newCentroids
+		km.iterations = iter + 1
+		km.recordIteration(changes, maxShift, iterStart)
+
+		if km.isConverged(iter, changes, maxShift) {
+			break
+		}
+	}
+	return nil
+}
+
+// initBounds assigns each vector to its closest centroid, and initializes its upper bound to the distance
+// to that centroid and its lower bound to the distance to the second-closest centroid.
+func (km *HamerlyClusterer) initBounds(ctx context.Context) error {
+	parallelFor(km.vectorCnt, km.workers, func(start, end int) {
+		for x := start; x < end; x++ {
+			if (x-start)%ctxCheckInterval == 0 && ctx.Err() != nil {
+				return
+			}
+			km.assignments[x], km.upper[x], km.lower[x] = km.closestTwo(x, -1, math.MaxFloat64)
+		}
+	})
+	return ctx.Err()
+}
+
+// closestTwo returns the centroid closest to vector x with the distance to it, and the distance to the
+// second-closest centroid. current is the centroid x is assigned to (-1 if none) and currentDist the distance
+// to it: another centroid only replaces it if it is strictly closer, like in ElkanClusterer.
+func (km *HamerlyClusterer) closestTwo(x int, current int, currentDist float64) (closest int, minDist, secondDist float64) {
+	closest, minDist, secondDist = current, currentDist, math.MaxFloat64
+	for c := range km.centroids {
+		if c == current {
+			continue
+		}
+		dist := km.distFn(km.vectorList[x], km.centroids[c])
+		if dist < minDist {
+			closest, minDist, secondDist = c, dist, minDist
+		} else if dist < secondDist {
+			secondDist = dist
+		}
+	}
+	return closest, minDist, secondDist
+}
+
+// computeCentroidDistances computes s(c) = 0.5 x min{d(c, c') | c' != c} for all centers c.
+func (km *HamerlyClusterer) computeCentroidDistances() {
+	for c := range km.minHalfInterCentroidDist {
+		km.minHalfInterCentroidDist[c] = math.MaxFloat64
+	}
+
+	// each chunk computes the rows [start, end) of the upper triangle of the distance matrix, and merges
+	// its partial minimums. The min does not depend on the merge order, so the result is deterministic.
+	var mu sync.Mutex
+	parallelFor(km.clusterCnt, km.workers, func(start, end int) {
+		partial := make([]float64, km.clusterCnt)
+		for c := range partial {
+			partial[c] = math.MaxFloat64
+		}
+		for r := start; r < end; r++ {
+			for c := r + 1; c < km.clusterCnt; c++ {
+				dist := 0.5 * km.distFn(km.centroids[r], km.centroids[c])
+				partial[r] = math.Min(partial[r], dist)
+				partial[c] = math.Min(partial[c], dist)
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for c := range partial {
+			km.minHalfInterCentroidDist[c] = math.Min(km.minHalfInterCentroidDist[c], partial[c])
+		}
+	})
+}
+
+// assignData assigns each vector to the nearest centroid and returns the number of vectors that changed their cluster.
+func (km *HamerlyClusterer) assignData(ctx context.Context) (int, error) {
+	var changes int64
+
+	parallelFor(km.vectorCnt, km.workers, func(start, end int) {
+		var chunkChanges int64
+		for x := start; x < end; x++ {
+			if (x-start)%ctxCheckInterval == 0 && ctx.Err() != nil {
+				return
+			}
+			if km.assignVector(x) {
+				chunkChanges++
+			}
+		}
+		atomic.AddInt64(&changes, chunkChanges)
+	})
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return int(changes), nil
+}
+
+// assignVector assigns a single vector and returns true if the vector changed its cluster.
+func (km *HamerlyClusterer) assignVector(x int) bool {
+	cx := km.assignments[x]
+
+	// if u(x) <= max{ s(c(x)), l(x) }, no other centroid can be closer than c(x).
+	bound := math.Max(km.minHalfInterCentroidDist[cx], km.lower[x])
+	if km.upper[x] <= bound {
+		return false
+	}
+
+	// tighten the upper bound, and test again.
+	km.upper[x] = km.distFn(km.vectorList[x], km.centroids[cx])
+	if km.upper[x] <= bound {
+		return false
+	}
+
+	km.assignments[x], km.upper[x], km.lower[x] = km.closestTwo(x, cx, km.upper[x])
+	return km.assignments[x] != cx
+}
+
+// updateBounds updates the lower and upper bounds for each vector and returns the largest centroid shift.
+func (km *HamerlyClusterer) updateBounds(newCentroids []*mat.VecDense) (maxShift float64) {
+	centroidShiftDist, maxShift := km.centroidShifts(newCentroids)
+
+	// the lower bound of a vector assigned to the centroid that moved the most only needs to decrease
+	// by the second largest shift, as it bounds the distance to the other centroids.
+	farthestMoved := 0
+	for c := range centroidShiftDist {
+		if centroidShiftDist[c] > centroidShiftDist[farthestMoved] {
+			farthestMoved = c
+		}
+	}
+	var secondMaxShift float64
+	for c := range centroidShiftDist {
+		if c != farthestMoved {
+			secondMaxShift = math.Max(secondMaxShift, centroidShiftDist[c])
+		}
+	}
+
+	parallelFor(km.vectorCnt, km.workers, func(start, end int) {
+		for x := start; x < end; x++ {
+			cx := km.assignments[x]
+			km.upper[x] += centroidShiftDist[cx]
+			if cx == farthestMoved {
+				km.lower[x] = math.Max(km.lower[x]-secondMaxShift, 0)
+			} else {
+				km.lower[x] = math.Max(km.lower[x]-maxShift, 0)
+			}
+		}
+	})
+	return maxShift
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"context"
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"reflect"
+	"testing"
+)
+
+func TestHamerlyClusterer_Cluster(t *testing.T) {
+	vectorList := [][]float64{
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+	}
+	km, err := NewHamerlyClusterer(vectorList, 2, WithInit(kmeans.Random))
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	got, err := km.Cluster()
+	if err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+	want := [][]float64{
+		{1, 2, 3.6666666666666665, 4.666666666666666},
+		{10, 3.333333333333333, 4, 5},
+	}
+	if !assertx.InEpsilonF64Slices(want, got) {
+		t.Errorf("Cluster() got = %v, want %v", got, want)
+	}
+	if !assertx.InEpsilonF64(12, km.SSE()) {
+		t.Errorf("SSE() got = %v, want %v", km.SSE(), 12)
+	}
+}
+
+func TestHamerlyClusterer_SameAsElkan(t *testing.T) {
+	data := make([][]float64, 500)
+	populateRandData(500, 8, data)
+
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{
+			name: "Test 1 - L2",
+			opts: []Option{WithSeed(3)},
+		},
+		{
+			name: "Test 2 - Spherical",
+			opts: []Option{WithSeed(5), WithDistance(kmeans.InnerProduct), WithNormalize(true)},
+		},
+		{
+			name: "Test 3 - Workers and empty clusters",
+			opts: []Option{WithSeed(7), WithWorkers(3), WithInit(kmeans.Random),
+				WithEmptyClusterPolicy(kmeans.EmptyClusterFarthestPoint)},
+		},
+		{
+			name: "Test 4 - Restarts",
+			opts: []Option{WithRestarts(3), WithConcurrentRestarts(true)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ekm, err := NewElkanClusterer(data, 16, tt.opts...)
+			if err != nil {
+				t.Fatalf("Error while creating KMeans object %v", err)
+			}
+			if _, err = ekm.Cluster(); err != nil {
+				t.Fatalf("Cluster() error = %v", err)
+			}
+			hkm, err := NewHamerlyClusterer(data, 16, tt.opts...)
+			if err != nil {
+				t.Fatalf("Error while creating KMeans object %v", err)
+			}
+			if _, err = hkm.Cluster(); err != nil {
+				t.Fatalf("Cluster() error = %v", err)
+			}
+
+			want, got := ekm.Result(), hkm.Result()
+			if !reflect.DeepEqual(want.Labels, got.Labels) {
+				t.Errorf("Labels differ from ElkanClusterer")
+			}
+			if !assertx.InEpsilonF64Slices(want.Centroids, got.Centroids) {
+				t.Errorf("Centroids differ from ElkanClusterer")
+			}
+			if want.Iterations != got.Iterations || want.ConvergenceReason != got.ConvergenceReason {
+				t.Errorf("Iterations got = %v (%v), want %v (%v)",
+					got.Iterations, got.ConvergenceReason, want.Iterations, want.ConvergenceReason)
+			}
+		})
+	}
+}
+
+func TestHamerlyClusterer_ClusterContext(t *testing.T) {
+	data := make([][]float64, 200)
+	populateRandData(200, 4, data)
+
+	km, err := NewHamerlyClusterer(data, 5)
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = km.ClusterContext(ctx); err == nil {
+		t.Errorf("ClusterContext() with a cancelled context should fail")
+	}
+}
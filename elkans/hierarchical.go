@@ -0,0 +1,220 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"context"
+	"github.com/arjunsk/kmeans"
+	moarray2 "github.com/arjunsk/kmeans/utils/moarray"
+	"github.com/arjunsk/kmeans/utils/moerr"
+	"gonum.org/v1/gonum/mat"
+)
+
+// HierarchicalClusterer is hierarchical kmeans, also known as a vocabulary tree. The vectors are clustered
+// into branching clusters with an ElkanClusterer run, and each cluster is clustered again the same way, down
+// to the given depth. The leaves are the clusters of the flat clustering, so there are up to
+// branching^depth of them, and Lookup finds the leaf of a vector by descending the tree with
+// O(branching*depth) distance computations instead of O(branching^depth).
+//
+// A node with less than branching vectors is not split, nor is a node whose run gives a single non-empty
+// cluster, so the tree may be unbalanced. The options are passed to every run, except WithInitialCentroids
+// which is not supported, and WithAlgorithm and WithYinyangGroups which do not apply to an ElkanClusterer.
+// The seed of a run is the configured seed plus the split order.
+//
+// Ref Paper: https://doi.org/10.1109/CVPR.2006.264
+type HierarchicalClusterer struct {
+	elkanRuns
+	branching int
+	depth     int
+	normalize bool
+	workers   int
+
+	tree                *kmeans.ClusterTreeNode
+	lookupTree          *lookupNode
+	labels              []int
+	iterations          int
+	emptyClusterReseeds int
+}
+
+// lookupNode mirrors an inner node of the tree, with an Assigner for the centroids of its children.
+type lookupNode struct {
+	assigner *Assigner
+	children []*lookupNode // nil for a leaf.
+	leafID   int
+}
+
+var _ kmeans.Clusterer = new(HierarchicalClusterer)
+
+// NewHierarchicalClusterer returns a HierarchicalClusterer that splits the vectors into branching clusters
+// per node, down to depth levels. The defaults of the runs can be overridden using the With* options.
+func NewHierarchicalClusterer(vectors [][]float64, branching, depth int, opts ...Option) (*HierarchicalClusterer, error) {
+	if branching < 2 {
+		return nil, moerr.NewInternalErrorNoCtx("branching factor is out of bounds (must be >= 2)")
+	}
+	if depth < 1 {
+		return nil, moerr.NewInternalErrorNoCtx("depth is out of bounds (must be >= 1)")
+	}
+	runs, cfg, err := newElkanRuns("hierarchical kmeans", vectors, branching, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HierarchicalClusterer{
+		elkanRuns: runs,
+		branching: branching,
+		depth:     depth,
+		normalize: cfg.normalize,
+		workers:   cfg.workers,
+	}, nil
+}
+
+// InitCentroids does nothing: the centroids of each node are initialized by its kmeans run.
+func (km *HierarchicalClusterer) InitCentroids() error {
+	return nil
+}
+
+// Cluster builds the tree and returns the centroids of the leaves, in LeafID order.
+func (km *HierarchicalClusterer) Cluster() ([][]float64, error) {
+	return km.ClusterContext(context.Background())
+}
+
+// ClusterContext is like Cluster, but stops when ctx is done and returns its error.
+func (km *HierarchicalClusterer) ClusterContext(ctx context.Context) ([][]float64, error) {
+	km.tree, km.lookupTree, km.labels = nil, nil, nil
+	km.iterations, km.emptyClusterReseeds = 0, 0
+
+	// the nodes are split level by level, so the split order of a level is below the one of the next level.
+	root := newRootNode(km.vectorList, km.distFn)
+	queue := []*kmeans.ClusterTreeNode{root}
+	for order := 0; len(queue) > 0; {
+		node := queue[0]
+		queue = queue[1:]
+		if node.Depth == km.depth || node.Size < int64(km.branching) {
+			continue
+		}
+
+		split, err := km.split(ctx, node, order)
+		if err != nil {
+			return nil, err
+		}
+		if split {
+			queue = append(queue, node.Children...)
+			order++
+		}
+	}
+
+	var centroids [][]float64
+	km.labels, centroids = labelLeaves(root.Leaves(), len(km.vectors))
+	km.tree = root
+	km.lookupTree = km.newLookupNode(root)
+	if root.IsLeaf() {
+		// the root was not split: descend from a node with the root as single child, which checks the vectors.
+		rootCentroid := []*mat.VecDense{moarray2.ToGonumVector[float64](root.Centroid)}
+		km.lookupTree = &lookupNode{
+			assigner: newAssigner(rootCentroid, km.distFn, km.normalize, km.workers),
+			children: []*lookupNode{km.lookupTree},
+			leafID:   -1,
+		}
+	}
+	return centroids, nil
+}
+
+// split clusters the members of node into branching clusters, and returns false if only one is not empty.
+func (km *HierarchicalClusterer) split(ctx context.Context, node *kmeans.ClusterTreeNode, order int) (bool, error) {
+	run, err := km.newRun(km.subset(node.Members), km.branching, km.seed+int64(order))
+	if err != nil {
+		return false, err
+	}
+	if _, err = run.ClusterContext(ctx); err != nil {
+		return false, err
+	}
+	result := run.Result()
+	km.iterations += result.Iterations
+	km.emptyClusterReseeds += result.EmptyClusterReseeds
+
+	children := newChildNodes(node, result)
+	if len(children) < 2 {
+		return false, nil
+	}
+	node.Children = children
+	node.SplitOrder = order
+	return true, nil
+}
+
+func (km *HierarchicalClusterer) newLookupNode(node *kmeans.ClusterTreeNode) *lookupNode {
+	if node.IsLeaf() {
+		return &lookupNode{leafID: node.LeafID}
+	}
+
+	centroids := make([]*mat.VecDense, len(node.Children))
+	children := make([]*lookupNode, len(node.Children))
+	for c, child := range node.Children {
+		centroids[c] = moarray2.ToGonumVector[float64](child.Centroid)
+		children[c] = km.newLookupNode(child)
+	}
+	return &lookupNode{
+		assigner: newAssigner(centroids, km.distFn, km.normalize, km.workers),
+		children: children,
+		leafID:   -1,
+	}
+}
+
+// Lookup returns the LeafID of each of the input vectors, found by descending the tree to the closest child
+// at each level. The vectors are processed in parallel. As the descent is greedy, the leaf may not hold the
+// closest leaf centroid, and a clustered vector may not be found in the leaf it was clustered into.
+func (km *HierarchicalClusterer) Lookup(vectors [][]float64) ([]int, error) {
+	if km.lookupTree == nil {
+		return nil, moerr.NewInternalErrorNoCtx("clusterer is not trained yet")
+	}
+	gonumVectors, err := km.lookupTree.assigner.toGonumVectors(vectors)
+	if err != nil {
+		return nil, err
+	}
+
+	leafIDs := make([]int, len(gonumVectors))
+	parallelFor(len(gonumVectors), km.workers, func(start, end int) {
+		for x := start; x < end; x++ {
+			node := km.lookupTree
+			for node.children != nil {
+				c, _ := node.assigner.nearest(gonumVectors[x])
+				node = node.children[c]
+			}
+			leafIDs[x] = node.leafID
+		}
+	})
+	return leafIDs, nil
+}
+
+// Tree returns the tree, or nil before Cluster.
+func (km *HierarchicalClusterer) Tree() *kmeans.ClusterTreeNode {
+	return km.tree
+}
+
+// SSE returns the sum of squared errors of the leaves.
+func (km *HierarchicalClusterer) SSE() float64 {
+	if km.tree == nil {
+		return 0
+	}
+	return km.Result().SSE
+}
+
+// Result returns the clustering of the leaves, or nil before Cluster. Iterations and EmptyClusterReseeds add
+// up the kmeans runs, and ConvergenceReason is not set, as each run stops on its own.
+func (km *HierarchicalClusterer) Result() *kmeans.ClusterResult {
+	if km.tree == nil {
+		return nil
+	}
+	return newTreeResult(km.tree, km.labels, km.iterations, km.emptyClusterReseeds)
+}
@@ -0,0 +1,210 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// nestedBlobs returns n vectors drawn around 9 centers, in 3 groups of 3 close centers.
+func nestedBlobs(n, dim int) [][]float64 {
+	random := rand.New(rand.NewSource(kmeans.DefaultRandSeed))
+	vectors := make([][]float64, n)
+	for i := range vectors {
+		vectors[i] = make([]float64, dim)
+		for d := range vectors[i] {
+			vectors[i][d] = float64((i%3)*1000+(i/3%3)*100) + random.NormFloat64()
+		}
+	}
+	return vectors
+}
+
+func TestHierarchicalClusterer_Cluster(t *testing.T) {
+	data := nestedBlobs(900, 4)
+	km, err := NewHierarchicalClusterer(data, 3, 2)
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	if _, err = km.Lookup(data); err == nil {
+		t.Errorf("Lookup() before Cluster(), want error")
+	}
+	centroids, err := km.Cluster()
+	if err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+	if len(centroids) != 9 {
+		t.Fatalf("Cluster() got %d centroids, want 9", len(centroids))
+	}
+
+	tree := km.Tree()
+	if len(tree.Children) != 3 {
+		t.Errorf("Tree() root got %d children, want 3", len(tree.Children))
+	}
+	for _, child := range tree.Children {
+		if len(child.Children) != 3 || child.SplitOrder <= tree.SplitOrder {
+			t.Errorf("Tree() level 1 got %d children and SplitOrder=%d, want 3 and > 0",
+				len(child.Children), child.SplitOrder)
+		}
+		// the first level separates the groups, the second the centers of a group.
+		for _, x := range child.Members {
+			if x%3 != child.Members[0]%3 {
+				t.Errorf("Tree() level 1 node holds vectors of groups %d and %d", x%3, child.Members[0]%3)
+			}
+		}
+		for _, leaf := range child.Children {
+			if leaf.Size != 100 || leaf.Depth != 2 {
+				t.Errorf("Tree() leaf got Size=%d Depth=%d, want 100, 2", leaf.Size, leaf.Depth)
+			}
+			for _, x := range leaf.Members {
+				if x%9 != leaf.Members[0]%9 {
+					t.Errorf("Tree() leaf holds vectors of centers %d and %d", x%9, leaf.Members[0]%9)
+				}
+			}
+		}
+	}
+
+	got, err := km.Lookup(data)
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if want := km.Result().Labels; !reflect.DeepEqual(want, got) {
+		t.Errorf("Lookup() differs from Result().Labels")
+	}
+	if _, err = km.Lookup([][]float64{{1, 2}}); err == nil {
+		t.Errorf("Lookup() with a different dimension, want error")
+	}
+}
+
+func TestHierarchicalClusterer_SingleLevel(t *testing.T) {
+	data := blobs(500, 5, 4, 2)
+
+	flat, err := NewElkanClusterer(data, 5)
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	if _, err = flat.Cluster(); err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+
+	km, err := NewHierarchicalClusterer(data, 5, 1)
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	if _, err = km.Cluster(); err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+
+	want, got := flat.Result(), km.Result()
+	if !reflect.DeepEqual(want.Labels, got.Labels) || !reflect.DeepEqual(want.Centroids, got.Centroids) {
+		t.Errorf("Result() with depth 1 differs from ElkanClusterer")
+	}
+	if !assertx.InEpsilonF64(flat.SSE(), km.SSE()) {
+		t.Errorf("SSE() got = %v, want %v", km.SSE(), flat.SSE())
+	}
+}
+
+func TestNewHierarchicalClusterer(t *testing.T) {
+	tests := []struct {
+		name       string
+		vectors    [][]float64
+		branching  int
+		depth      int
+		opts       []Option
+		wantLeaves int
+		wantErr    bool
+	}{
+		{
+			name:       "Test 1 - small nodes are not split",
+			vectors:    [][]float64{{1}, {2}, {10}, {11}, {12}, {20}},
+			branching:  3,
+			depth:      3,
+			wantLeaves: 5,
+		},
+		{
+			name:       "Test 2 - identical vectors",
+			vectors:    [][]float64{{1}, {1}, {1}},
+			branching:  2,
+			depth:      2,
+			wantLeaves: 1,
+		},
+		{
+			name:      "Test 3 - branching 1",
+			vectors:   [][]float64{{1}, {2}},
+			branching: 1,
+			depth:     1,
+			wantErr:   true,
+		},
+		{
+			name:      "Test 4 - depth 0",
+			vectors:   [][]float64{{1}, {2}},
+			branching: 2,
+			depth:     0,
+			wantErr:   true,
+		},
+		{
+			name:      "Test 5 - initial centroids",
+			vectors:   [][]float64{{1}, {2}},
+			branching: 2,
+			depth:     1,
+			opts:      []Option{WithInitialCentroids([][]float64{{1}, {2}})},
+			wantErr:   true,
+		},
+		{
+			name:      "Test 6 - branching larger than the vector count",
+			vectors:   [][]float64{{1}, {2}},
+			branching: 3,
+			depth:     1,
+			wantErr:   true,
+		},
+		{
+			// the runs are ElkanClusterer runs, so the yinyang groups do not apply to them.
+			name:       "Test 7 - yinyang groups above the branching factor",
+			vectors:    [][]float64{{1}, {2}, {10}, {11}},
+			branching:  2,
+			depth:      1,
+			opts:       []Option{WithAlgorithm(kmeans.Yinyang), WithYinyangGroups(3)},
+			wantLeaves: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, err := NewHierarchicalClusterer(tt.vectors, tt.branching, tt.depth, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewHierarchicalClusterer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			centroids, err := km.Cluster()
+			if err != nil {
+				t.Fatalf("Cluster() error = %v", err)
+			}
+			if len(centroids) != tt.wantLeaves {
+				t.Errorf("Cluster() got %d centroids, want %d", len(centroids), tt.wantLeaves)
+			}
+			got, err := km.Lookup(tt.vectors)
+			if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+			if want := km.Result().Labels; !reflect.DeepEqual(want, got) {
+				t.Errorf("Lookup() got = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -19,50 +19,157 @@ import (
 	"gonum.org/v1/gonum/mat"
 	"math"
 	"math/rand"
+	"sort"
 )
 
+// Initializer chooses the initial centroids. An Initializer, and the rand.Rand given to the *WithRand
+// constructors, is not safe for concurrent use: a rand.Rand should not be shared with another goroutine.
 type Initializer interface {
 	InitCentroids(vectors []*mat.VecDense, k int) (centroids []*mat.VecDense)
 }
 
 var _ Initializer = (*Random)(nil)
 
-// Random initializes the centroids with random centroids from the vector list.
+// Random initializes the centroids with k distinct vectors sampled without replacement from the vector list
+// (Forgy's method). Identical vectors are only picked once, unless there are less than k distinct vectors, so
+// that no two initial centroids collapse into the same cluster.
 type Random struct {
-	rand rand.Rand
+	rand *rand.Rand
 }
 
 func NewRandomInitializer() Initializer {
+	return NewRandomInitializerWithRand(rand.New(rand.NewSource(kmeans.DefaultRandSeed)))
+}
+
+// NewRandomInitializerWithRand returns a Random initializer drawing from rnd.
+func NewRandomInitializerWithRand(rnd *rand.Rand) Initializer {
 	return &Random{
-		rand: *rand.New(rand.NewSource(kmeans.DefaultRandSeed)),
+		rand: rnd,
 	}
 }
 
 func (r *Random) InitCentroids(vectors []*mat.VecDense, k int) (centroids []*mat.VecDense) {
+	numSamples := len(vectors)
+	centroids = make([]*mat.VecDense, 0, k)
+
+	// partial Fisher-Yates shuffle: indices[:i] are the vectors drawn so far.
+	indices := make([]int, numSamples)
+	for i := range indices {
+		indices[i] = i
+	}
+	chosen := make(map[uint64][]*mat.VecDense)
+	var duplicates []int
+	for i := 0; i < numSamples && len(centroids) < k; i++ {
+		j := i + r.rand.Intn(numSamples-i)
+		indices[i], indices[j] = indices[j], indices[i]
+
+		vec := vectors[indices[i]]
+		hash := hashVector(vec)
+		if containsVector(chosen[hash], vec) {
+			duplicates = append(duplicates, indices[i])
+			continue
+		}
+		chosen[hash] = append(chosen[hash], vec)
+		centroids = append(centroids, vec)
+	}
+
+	// less than k distinct vectors: fill up with the duplicates.
+	for i := 0; len(centroids) < k; i++ {
+		centroids = append(centroids, vectors[duplicates[i]])
+	}
+	return centroids
+}
+
+// hashVector returns the FNV-1a hash of the bits of the vector elements.
+func hashVector(vec *mat.VecDense) uint64 {
+	hash := uint64(14695981039346656037)
+	for i := 0; i < vec.Len(); i++ {
+		hash ^= math.Float64bits(vec.AtVec(i))
+		hash *= 1099511628211
+	}
+	return hash
+}
+
+func containsVector(vecs []*mat.VecDense, vec *mat.VecDense) bool {
+	for _, v := range vecs {
+		if mat.Equal(v, vec) {
+			return true
+		}
+	}
+	return false
+}
+
+var _ Initializer = (*RandomPartition)(nil)
+
+// RandomPartition initializes the centroids by assigning every vector to a random cluster, and using the
+// mean of each cluster as its centroid. The initial centroids are close to the mean of the whole data, which
+// makes it less sensitive to outliers than sampling vectors, but slower to converge.
+type RandomPartition struct {
+	rand *rand.Rand
+}
+
+func NewRandomPartitionInitializer() Initializer {
+	return NewRandomPartitionInitializerWithRand(rand.New(rand.NewSource(kmeans.DefaultRandSeed)))
+}
+
+// NewRandomPartitionInitializerWithRand returns a RandomPartition initializer drawing from rnd.
+func NewRandomPartitionInitializerWithRand(rnd *rand.Rand) Initializer {
+	return &RandomPartition{
+		rand: rnd,
+	}
+}
+
+func (rp *RandomPartition) InitCentroids(vectors []*mat.VecDense, k int) (centroids []*mat.VecDense) {
 	centroids = make([]*mat.VecDense, k)
-	for i := 0; i < k; i++ {
-		randIdx := r.rand.Intn(len(vectors))
-		centroids[i] = vectors[randIdx]
+	for c := range centroids {
+		centroids[c] = mat.NewVecDense(vectors[0].Len(), nil)
+	}
+
+	membersCount := make([]int64, k)
+	for _, vec := range vectors {
+		c := rp.rand.Intn(k)
+		membersCount[c]++
+		centroids[c].AddVec(centroids[c], vec)
+	}
+
+	for c := range centroids {
+		if membersCount[c] == 0 {
+			// no vector was drawn for this cluster, so fall back to a random vector.
+			centroids[c].CopyVec(vectors[rp.rand.Intn(len(vectors))])
+			continue
+		}
+		centroids[c].ScaleVec(1/float64(membersCount[c]), centroids[c])
 	}
 	return centroids
 }
 
 // KMeansPlusPlus initializes the centroids using kmeans++ algorithm.
-// Complexity: O(k*n*k); n = number of vectors, k = number of clusters
+// Complexity: O(n*k) distance computations; n = number of vectors, k = number of clusters
 // Ref Paper: https://theory.stanford.edu/~sergei/papers/kMeansPP-soda.pdf
 // The reason why we have kmeans++ is that it is more stable than random initialization.
 // For example, we have 3 clusters.
 // Using random, we could get 3 centroids: 1&2 which are close to each other and part of cluster 1. 3 is in the middle of 2&3.
 // Using kmeans++, we are sure that 3 centroids are farther away from each other.
 type KMeansPlusPlus struct {
-	rand   rand.Rand
-	distFn kmeans.DistanceFunction
+	rand    *rand.Rand
+	distFn  kmeans.DistanceFunction
+	workers int
 }
 
 func NewKMeansPlusPlusInitializer(distFn kmeans.DistanceFunction) Initializer {
+	return NewKMeansPlusPlusInitializerWithRand(distFn, rand.New(rand.NewSource(kmeans.DefaultRandSeed)))
+}
+
+// NewKMeansPlusPlusInitializerWithRand returns a KMeansPlusPlus initializer drawing from rnd.
+func NewKMeansPlusPlusInitializerWithRand(distFn kmeans.DistanceFunction, rnd *rand.Rand) Initializer {
+	return newKMeansPlusPlusInitializer(distFn, rnd, 0)
+}
+
+func newKMeansPlusPlusInitializer(distFn kmeans.DistanceFunction, rnd *rand.Rand, workers int) *KMeansPlusPlus {
 	return &KMeansPlusPlus{
-		rand:   *rand.New(rand.NewSource(kmeans.DefaultRandSeed)),
-		distFn: distFn,
+		rand:    rnd,
+		distFn:  distFn,
+		workers: workers,
 	}
 }
 
@@ -73,38 +180,60 @@ func (kpp *KMeansPlusPlus) InitCentroids(vectors []*mat.VecDense, k int) (centro
 	// 1. start with a random center
 	centroids[0] = vectors[kpp.rand.Intn(numSamples)]
 
-	distances := make([]float64, numSamples)
-	for j := range distances {
-		distances[j] = math.MaxFloat64
+	// minDistances[x] is D(x)^2, the squared distance of x to the closest center chosen so far.
+	// It is updated incrementally with the distance to the last chosen center only, which gives
+	// the exact min distance to all the chosen centers without recomputing them.
+	minDistances := make([]float64, numSamples)
+	for j := range minDistances {
+		minDistances[j] = math.MaxFloat64
 	}
+	cumDistances := make([]float64, numSamples)
 
 	for nextCentroidIdx := 1; nextCentroidIdx < k; nextCentroidIdx++ {
 
-		// 2. for each data point, compute the min distance to the existing centers
-		var totalDistToExistingCenters float64
-		for vecIdx := range vectors {
-			// this is a deviation from standard kmeans.here we are not using minDistance to all the existing centers.
-			// This code was very slow: https://github.com/matrixorigin/matrixone/blob/77ff1452bd56cd93a10e3327632adebdbaf279cb/pkg/sql/plan/function/functionAgg/algos/kmeans/elkans/initializer.go#L81-L86
-			// but instead we are using the distance to the last center that was chosen.
-			distance := kpp.distFn(vectors[vecIdx], centroids[nextCentroidIdx-1])
-			distance *= distance
-			if distance < distances[vecIdx] {
-				distances[vecIdx] = distance
+		// 2. for each data point, update the min distance to the existing centers.
+		lastCentroid := centroids[nextCentroidIdx-1]
+		parallelFor(numSamples, kpp.workers, func(start, end int) {
+			for vecIdx := start; vecIdx < end; vecIdx++ {
+				distance := kpp.distFn(vectors[vecIdx], lastCentroid)
+				distance *= distance
+				if distance < minDistances[vecIdx] {
+					minDistances[vecIdx] = distance
+				}
 			}
-			totalDistToExistingCenters += distances[vecIdx]
+		})
+
+		// the prefix sum is computed sequentially, so that the draw does not depend on the number of workers.
+		var totalDistToExistingCenters float64
+		for vecIdx, distance := range minDistances {
+			totalDistToExistingCenters += distance
+			cumDistances[vecIdx] = totalDistToExistingCenters
 		}
 
 		// 3. choose the next random center, using a weighted probability distribution
 		// where it is chosen with probability proportional to D(x)^2
 		// Ref: https://en.wikipedia.org/wiki/K-means%2B%2B#Improved_initialization_algorithm
-		target := kpp.rand.Float64() * totalDistToExistingCenters
-		for idx, distance := range distances {
-			target -= distance
-			if target <= 0 {
-				centroids[nextCentroidIdx] = vectors[idx]
-				break
-			}
-		}
+		centroids[nextCentroidIdx] = vectors[weightedIndex(kpp.rand, cumDistances)]
 	}
 	return centroids
 }
+
+// weightedIndex draws an index with probability proportional to its weight, given the prefix sums
+// of the weights. If all the weights are 0, the index is drawn uniformly.
+func weightedIndex(rnd *rand.Rand, cumWeights []float64) int {
+	n := len(cumWeights)
+	total := cumWeights[n-1]
+	if total == 0 {
+		return rnd.Intn(n)
+	}
+
+	target := rnd.Float64() * total
+	idx := sort.Search(n, func(i int) bool {
+		return cumWeights[i] > target
+	})
+	if idx == n {
+		// target is only reachable due to float rounding of the prefix sum.
+		idx = n - 1
+	}
+	return idx
+}
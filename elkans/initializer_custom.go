@@ -0,0 +1,74 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/moerr"
+	"gonum.org/v1/gonum/mat"
+	"math/rand"
+)
+
+// InitializerFunc is an adapter to use an ordinary function as an Initializer.
+type InitializerFunc func(vectors []*mat.VecDense, k int) []*mat.VecDense
+
+func (f InitializerFunc) InitCentroids(vectors []*mat.VecDense, k int) []*mat.VecDense {
+	return f(vectors, k)
+}
+
+// InitializerConfig is what the clusterer provides to an InitializerFactory, so that a custom initializer
+// measures distances like the clusterer and is reproducible for a given seed.
+type InitializerConfig struct {
+	// DistFn is the distance function of the clusterer.
+	DistFn kmeans.DistanceFunction
+	// Rand is the random source of the current run. It is not safe for concurrent use.
+	Rand *rand.Rand
+	// Workers is the number of goroutines the initializer may use, 0 meaning GOMAXPROCS.
+	Workers int
+}
+
+// InitializerFactory builds the Initializer of a clustering run, see WithInitializerFactory. It is called once
+// per run, and concurrently when the restarts are concurrent.
+type InitializerFactory func(cfg InitializerConfig) Initializer
+
+// newInitializer returns the initializer of the current run of km: the one set with WithInitializer or
+// WithInitializerFactory, else the built-in one of km.initType.
+func (km *clusterer) newInitializer() (Initializer, error) {
+	cfg := InitializerConfig{
+		DistFn:  km.distFn,
+		Rand:    km.rand,
+		Workers: km.workers,
+	}
+	if km.initializerFactory != nil {
+		return km.initializerFactory(cfg), nil
+	}
+
+	switch km.initType {
+	case kmeans.Random:
+		return NewRandomInitializerWithRand(km.rand), nil
+	case kmeans.KmeansPlusPlus:
+		return newKMeansPlusPlusInitializer(km.distFn, km.rand, km.workers), nil
+	case kmeans.KmeansParallel:
+		return newKMeansParallelInitializer(km.distFn, km.rand, km.initRounds, km.initOversampling, km.workers), nil
+	case kmeans.RandomPartition:
+		return NewRandomPartitionInitializerWithRand(km.rand), nil
+	case kmeans.FarthestFirst:
+		return newFarthestFirstInitializer(km.distFn, km.rand, km.workers), nil
+	case kmeans.PCASplit:
+		return NewPCASplitInitializer(), nil
+	default:
+		return nil, moerr.NewInternalErrorNoCtx("init type %d is not supported", km.initType)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"gonum.org/v1/gonum/mat"
+	"testing"
+)
+
+func TestElkanClusterer_WithInitializer(t *testing.T) {
+	// a custom initializer picking the first k vectors.
+	first := InitializerFunc(func(vectors []*mat.VecDense, k int) []*mat.VecDense {
+		return vectors[:k]
+	})
+
+	vectorList := [][]float64{{1}, {3}, {10}, {12}}
+	if _, err := NewElkanClusterer(vectorList, 2, WithInit(kmeans.InitType(100))); err == nil {
+		t.Fatalf("NewElkanClusterer() with an unknown init type should fail")
+	}
+	ekm, err := NewElkanClusterer(vectorList, 2, WithInit(kmeans.InitType(100)), WithInitializer(first))
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	if err = ekm.InitCentroids(); err != nil {
+		t.Fatalf("InitCentroids() error = %v", err)
+	}
+	if got := ekm.centroids[0].AtVec(0); got != 1 {
+		t.Errorf("InitCentroids() first centroid = %v, want %v", got, 1)
+	}
+}
+
+func TestElkanClusterer_WithInitializerFactory(t *testing.T) {
+	vectorList := [][]float64{{1}, {3}, {10}, {12}}
+
+	var got InitializerConfig
+	ekm, err := NewElkanClusterer(vectorList, 2, WithWorkers(3), WithInitializerFactory(func(cfg InitializerConfig) Initializer {
+		got = cfg
+		return NewFarthestFirstInitializerWithRand(cfg.DistFn, cfg.Rand)
+	}))
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	if _, err = ekm.Cluster(); err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+	if got.DistFn == nil || got.Rand != ekm.rand || got.Workers != 3 {
+		t.Errorf("WithInitializerFactory() factory got config %+v", got)
+	}
+	if !assertx.InEpsilonF64(4, ekm.SSE()) {
+		t.Errorf("SSE() got = %v, want %v", ekm.SSE(), 4)
+	}
+
+	// the output of a custom initializer is checked.
+	ekm, err = NewElkanClusterer(vectorList, 2, WithInitializerFactory(func(cfg InitializerConfig) Initializer {
+		return InitializerFunc(func(vectors []*mat.VecDense, k int) []*mat.VecDense {
+			return vectors[:k-1]
+		})
+	}))
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	if _, err = ekm.Cluster(); err == nil {
+		t.Errorf("Cluster() with too few initial centroids should fail")
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"math/rand"
+)
+
+var _ Initializer = (*FarthestFirst)(nil)
+
+// FarthestFirst initializes the centroids using the farthest-first traversal (Gonzalez's algorithm).
+// It starts with a random vector, and then repeatedly picks the vector with the largest distance to its
+// closest chosen center. It is the deterministic counterpart of kmeans++: the centroids are well spread,
+// but outliers are always picked, so it suits data without outliers.
+// Complexity: O(n*k) distance computations; n = number of vectors, k = number of clusters
+type FarthestFirst struct {
+	rand    *rand.Rand
+	distFn  kmeans.DistanceFunction
+	workers int
+}
+
+func NewFarthestFirstInitializer(distFn kmeans.DistanceFunction) Initializer {
+	return NewFarthestFirstInitializerWithRand(distFn, rand.New(rand.NewSource(kmeans.DefaultRandSeed)))
+}
+
+// NewFarthestFirstInitializerWithRand returns a FarthestFirst initializer drawing the first center from rnd.
+func NewFarthestFirstInitializerWithRand(distFn kmeans.DistanceFunction, rnd *rand.Rand) Initializer {
+	return newFarthestFirstInitializer(distFn, rnd, 0)
+}
+
+func newFarthestFirstInitializer(distFn kmeans.DistanceFunction, rnd *rand.Rand, workers int) *FarthestFirst {
+	return &FarthestFirst{
+		rand:    rnd,
+		distFn:  distFn,
+		workers: workers,
+	}
+}
+
+func (ff *FarthestFirst) InitCentroids(vectors []*mat.VecDense, k int) (centroids []*mat.VecDense) {
+	numSamples := len(vectors)
+	centroids = make([]*mat.VecDense, k)
+
+	// 1. start with a random center
+	centroids[0] = vectors[ff.rand.Intn(numSamples)]
+
+	// minDistances[x] is the distance of x to the closest center chosen so far, updated incrementally
+	// with the distance to the last chosen center only.
+	minDistances := make([]float64, numSamples)
+	for j := range minDistances {
+		minDistances[j] = math.MaxFloat64
+	}
+
+	for nextCentroidIdx := 1; nextCentroidIdx < k; nextCentroidIdx++ {
+
+		// 2. for each data point, update the min distance to the existing centers.
+		lastCentroid := centroids[nextCentroidIdx-1]
+		parallelFor(numSamples, ff.workers, func(start, end int) {
+			for vecIdx := start; vecIdx < end; vecIdx++ {
+				distance := ff.distFn(vectors[vecIdx], lastCentroid)
+				if distance < minDistances[vecIdx] {
+					minDistances[vecIdx] = distance
+				}
+			}
+		})
+
+		// 3. choose the farthest vector as the next center. The scan is sequential and ties go to the
+		// lowest index, so that the choice does not depend on the number of workers.
+		farthestIdx := 0
+		for vecIdx, distance := range minDistances {
+			if distance > minDistances[farthestIdx] {
+				farthestIdx = vecIdx
+			}
+		}
+		centroids[nextCentroidIdx] = vectors[farthestIdx]
+	}
+	return centroids
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"github.com/arjunsk/kmeans/utils/moarray"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestFarthestFirst_InitCentroids(t *testing.T) {
+	vectors := [][]float64{{0}, {1}, {2}, {10}, {20}}
+	gonumVectors, _ := moarray.ToGonumVectors[float64](vectors...)
+
+	for seed := int64(1); seed <= 10; seed++ {
+		r := NewFarthestFirstInitializerWithRand(L2Distance, rand.New(rand.NewSource(seed)))
+		got := moarray.ToMoArrays[float64](r.InitCentroids(gonumVectors, 3))
+
+		// after the random first center, the next ones are the vectors farthest from the chosen ones,
+		// ties going to the lowest index.
+		want := map[float64][][]float64{
+			0:  {{0}, {20}, {10}},
+			1:  {{1}, {20}, {10}},
+			2:  {{2}, {20}, {10}},
+			10: {{10}, {0}, {20}},
+			20: {{20}, {0}, {10}},
+		}[got[0][0]]
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("seed=%v: InitCentroids() = %v, want %v", seed, got, want)
+		}
+	}
+}
+
+func TestFarthestFirst_InitCentroids_Workers(t *testing.T) {
+	data := make([][]float64, 1000)
+	populateRandData(1000, 16, data)
+	gonumVectors, _ := moarray.ToGonumVectors[float64](data...)
+
+	want := newFarthestFirstInitializer(L2Distance, rand.New(rand.NewSource(7)), 1).InitCentroids(gonumVectors, 20)
+	for _, workers := range []int{0, 3, 8} {
+		got := newFarthestFirstInitializer(L2Distance, rand.New(rand.NewSource(7)), workers).InitCentroids(gonumVectors, 20)
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("workers=%v: InitCentroids() differs from single worker", workers)
+		}
+	}
+}
+
+func TestElkanClusterer_Cluster_FarthestFirst(t *testing.T) {
+	vectorList := [][]float64{
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+	}
+	ekm, err := NewElkanClusterer(vectorList, 2, WithInit(kmeans.FarthestFirst))
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	if _, err = ekm.Cluster(); err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+	if !assertx.InEpsilonF64(12, ekm.SSE()) {
+		t.Errorf("SSE() got = %v, want %v", ekm.SSE(), 12)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"math/rand"
+	"sync/atomic"
+)
+
+const (
+	// DefaultKMeansParallelRounds and DefaultKMeansParallelOversampling are the values of Bahmani et al.:
+	// l = 2k candidates per round, for 5 rounds.
+	DefaultKMeansParallelRounds       = 5
+	DefaultKMeansParallelOversampling = 2.0
+
+	// kmeansParallelReclusterIterations is the max number of weighted Lloyd iterations run on the candidates.
+	kmeansParallelReclusterIterations = 10
+)
+
+var _ Initializer = (*KMeansParallel)(nil)
+
+// KMeansParallel initializes the centroids using the k-means|| algorithm (scalable kmeans++).
+// Instead of making k passes over the data to pick one center per pass like kmeans++, it makes a few rounds,
+// and in each round it samples every vector independently with probability l * D(x)^2 / sum(D(x)^2), where
+// l = oversamplingFactor * k. The O(l * rounds) candidates are then weighted by the number of vectors closest
+// to them, and reclustered into k centroids using weighted kmeans++ followed by weighted Lloyd iterations.
+// Complexity: O(n*l*rounds) distance computations in rounds+1 parallel passes over the data, and
+// O(l*rounds*k) for the reclustering. kmeans++ makes O(n*k) distance computations, but in k sequential
+// passes, which is what limits it for a large k on many vectors.
+// Ref Paper: https://theory.stanford.edu/~sergei/papers/vldb12-kmpar.pdf
+type KMeansParallel struct {
+	rand               *rand.Rand
+	distFn             kmeans.DistanceFunction
+	rounds             int
+	oversamplingFactor float64
+	workers            int
+}
+
+// NewKMeansParallelInitializer returns a KMeansParallel initializer running `rounds` sampling rounds,
+// each sampling oversamplingFactor * k candidates in expectation.
+func NewKMeansParallelInitializer(distFn kmeans.DistanceFunction, rounds int, oversamplingFactor float64) Initializer {
+	return NewKMeansParallelInitializerWithRand(distFn, rand.New(rand.NewSource(kmeans.DefaultRandSeed)),
+		rounds, oversamplingFactor)
+}
+
+// NewKMeansParallelInitializerWithRand is like NewKMeansParallelInitializer, but draws from rnd.
+func NewKMeansParallelInitializerWithRand(distFn kmeans.DistanceFunction, rnd *rand.Rand,
+	rounds int, oversamplingFactor float64) Initializer {
+	return newKMeansParallelInitializer(distFn, rnd, rounds, oversamplingFactor, 0)
+}
+
+func newKMeansParallelInitializer(distFn kmeans.DistanceFunction, rnd *rand.Rand,
+	rounds int, oversamplingFactor float64, workers int) *KMeansParallel {
+	return &KMeansParallel{
+		rand:               rnd,
+		distFn:             distFn,
+		rounds:             rounds,
+		oversamplingFactor: oversamplingFactor,
+		workers:            workers,
+	}
+}
+
+func (kpl *KMeansParallel) InitCentroids(vectors []*mat.VecDense, k int) (centroids []*mat.VecDense) {
+	numSamples := len(vectors)
+
+	// minDistances[x] is D(x)^2 and nearest[x] is the index of the closest candidate.
+	minDistances := make([]float64, numSamples)
+	nearest := make([]int, numSamples)
+	for j := range minDistances {
+		minDistances[j] = math.MaxFloat64
+	}
+
+	// 1. start with a random candidate
+	candidates := []*mat.VecDense{vectors[kpl.rand.Intn(numSamples)]}
+	kpl.updateMinDistances(vectors, candidates, 0, minDistances, nearest)
+
+	// 2. oversample the candidates, proportionally to D(x)^2.
+	l := kpl.oversamplingFactor * float64(k)
+	for round := 0; round < kpl.rounds; round++ {
+		var psi float64
+		for _, distance := range minDistances {
+			psi += distance
+		}
+		if psi == 0 {
+			break
+		}
+
+		// the draws are sequential, so that the candidates do not depend on the number of workers.
+		newCandidatesStart := len(candidates)
+		for vecIdx, distance := range minDistances {
+			if kpl.rand.Float64() < l*distance/psi {
+				candidates = append(candidates, vectors[vecIdx])
+			}
+		}
+		kpl.updateMinDistances(vectors, candidates, newCandidatesStart, minDistances, nearest)
+	}
+
+	// 3. if the rounds were not enough, complete the candidates kmeans++ style.
+	cumDistances := make([]float64, numSamples)
+	for len(candidates) < k {
+		var total float64
+		for vecIdx, distance := range minDistances {
+			total += distance
+			cumDistances[vecIdx] = total
+		}
+		candidates = append(candidates, vectors[weightedIndex(kpl.rand, cumDistances)])
+		kpl.updateMinDistances(vectors, candidates, len(candidates)-1, minDistances, nearest)
+	}
+
+	// 4. weight each candidate by the number of vectors closest to it.
+	weights := make([]float64, len(candidates))
+	for _, c := range nearest {
+		weights[c]++
+	}
+
+	// 5. recluster the weighted candidates into k centroids.
+	return kpl.recluster(candidates, weights, k)
+}
+
+// updateMinDistances updates D(x)^2 and the nearest candidate of every vector with candidates[from:].
+func (kpl *KMeansParallel) updateMinDistances(vectors, candidates []*mat.VecDense, from int,
+	minDistances []float64, nearest []int) {
+	parallelFor(len(vectors), kpl.workers, func(start, end int) {
+		for vecIdx := start; vecIdx < end; vecIdx++ {
+			for c := from; c < len(candidates); c++ {
+				distance := kpl.distFn(vectors[vecIdx], candidates[c])
+				distance *= distance
+				if distance < minDistances[vecIdx] {
+					minDistances[vecIdx] = distance
+					nearest[vecIdx] = c
+				}
+			}
+		}
+	})
+}
+
+// recluster runs weighted kmeans++ on the candidates, followed by weighted Lloyd iterations.
+// The distances are computed in parallel, and the draws and sums sequentially, so that the centroids do not
+// depend on the number of workers.
+func (kpl *KMeansParallel) recluster(candidates []*mat.VecDense, weights []float64, k int) []*mat.VecDense {
+	numCandidates := len(candidates)
+
+	// weighted kmeans++: a candidate is chosen with probability proportional to weight * D(c)^2.
+	centroids := make([]*mat.VecDense, 0, k)
+	minDistances := make([]float64, numCandidates)
+	cumWeights := make([]float64, numCandidates)
+	var total float64
+	for c := range candidates {
+		minDistances[c] = math.MaxFloat64
+		total += weights[c]
+		cumWeights[c] = total
+	}
+	centroids = append(centroids, candidates[weightedIndex(kpl.rand, cumWeights)])
+	for len(centroids) < k {
+		lastCentroid := centroids[len(centroids)-1]
+		parallelFor(numCandidates, kpl.workers, func(start, end int) {
+			for c := start; c < end; c++ {
+				distance := kpl.distFn(candidates[c], lastCentroid)
+				distance *= distance
+				if distance < minDistances[c] {
+					minDistances[c] = distance
+				}
+			}
+		})
+		total = 0
+		for c := range candidates {
+			total += weights[c] * minDistances[c]
+			cumWeights[c] = total
+		}
+		centroids = append(centroids, candidates[weightedIndex(kpl.rand, cumWeights)])
+	}
+
+	// weighted Lloyd iterations.
+	assignments := make([]int, numCandidates)
+	for i := range assignments {
+		assignments[i] = -1
+	}
+	for iter := 0; iter < kmeansParallelReclusterIterations; iter++ {
+		var changes int64
+		parallelFor(numCandidates, kpl.workers, func(start, end int) {
+			var chunkChanges int64
+			for c := start; c < end; c++ {
+				closest, minDist := 0, math.MaxFloat64
+				for j := range centroids {
+					if distance := kpl.distFn(candidates[c], centroids[j]); distance < minDist {
+						closest, minDist = j, distance
+					}
+				}
+				if assignments[c] != closest {
+					assignments[c] = closest
+					chunkChanges++
+				}
+			}
+			atomic.AddInt64(&changes, chunkChanges)
+		})
+		if changes == 0 {
+			break
+		}
+
+		newCentroids := make([]*mat.VecDense, k)
+		clusterWeights := make([]float64, k)
+		for j := range newCentroids {
+			newCentroids[j] = mat.NewVecDense(candidates[0].Len(), nil)
+		}
+		for c, j := range assignments {
+			newCentroids[j].AddScaledVec(newCentroids[j], weights[c], candidates[c])
+			clusterWeights[j] += weights[c]
+		}
+		for j := range newCentroids {
+			if clusterWeights[j] == 0 {
+				// keep the previous centroid of an empty cluster.
+				newCentroids[j] = centroids[j]
+				continue
+			}
+			newCentroids[j].ScaleVec(1/clusterWeights[j], newCentroids[j])
+		}
+		centroids = newCentroids
+	}
+	return centroids
+}
@@ -0,0 +1,147 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"github.com/arjunsk/kmeans/utils/moarray"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestKMeansParallel_InitCentroids(t *testing.T) {
+	// 3 well separated groups of 30 vectors each.
+	random := rand.New(rand.NewSource(kmeans.DefaultRandSeed))
+	var vectors [][]float64
+	for _, center := range [][]float64{{0, 0}, {100, 100}, {0, 100}} {
+		for i := 0; i < 30; i++ {
+			vectors = append(vectors, []float64{center[0] + random.Float64(), center[1] + random.Float64()})
+		}
+	}
+	group := func(v []float64) int {
+		return int(v[0]/50) + 2*int(v[1]/50)
+	}
+
+	gonumVectors, _ := moarray.ToGonumVectors[float64](vectors...)
+	for seed := int64(1); seed <= 10; seed++ {
+		r := NewKMeansParallelInitializerWithRand(L2Distance, rand.New(rand.NewSource(seed)),
+			DefaultKMeansParallelRounds, DefaultKMeansParallelOversampling)
+		got := moarray.ToMoArrays[float64](r.InitCentroids(gonumVectors, 3))
+		seen := make(map[int]bool)
+		for _, centroid := range got {
+			seen[group(centroid)] = true
+		}
+		if len(got) != 3 || len(seen) != 3 {
+			t.Errorf("seed=%v: InitCentroids() = %v, want one centroid per group", seed, got)
+		}
+	}
+}
+
+func TestKMeansParallel_InitCentroids_Workers(t *testing.T) {
+	data := make([][]float64, 1000)
+	populateRandData(1000, 16, data)
+	gonumVectors, _ := moarray.ToGonumVectors[float64](data...)
+
+	want := newKMeansParallelInitializer(L2Distance, rand.New(rand.NewSource(7)), 3, 2, 1).InitCentroids(gonumVectors, 20)
+	for _, workers := range []int{0, 3, 8} {
+		got := newKMeansParallelInitializer(L2Distance, rand.New(rand.NewSource(7)), 3, 2, workers).InitCentroids(gonumVectors, 20)
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("workers=%v: InitCentroids() differs from single worker", workers)
+		}
+	}
+}
+
+func TestKMeansParallel_InitCentroids_Passes(t *testing.T) {
+	gonumVectors, _ := moarray.ToGonumVectors[float64](blobs(5000, 100, 4, 3)...)
+	k := 100
+
+	// with a single worker, a pass over the data computes the distances of each vector in a row. The vectors
+	// that are not candidates are only visited by the passes, so the passes are the fewest visits of a vector.
+	passes := func(init func(distFn kmeans.DistanceFunction)) int {
+		visits := make(map[*mat.VecDense]int)
+		var last *mat.VecDense
+		init(func(v1, v2 *mat.VecDense) float64 {
+			if v1 != last {
+				visits[v1]++
+				last = v1
+			}
+			return L2Distance(v1, v2)
+		})
+		fewest := math.MaxInt
+		for _, v := range gonumVectors {
+			if visits[v] < fewest {
+				fewest = visits[v]
+			}
+		}
+		return fewest
+	}
+
+	plusPlus := passes(func(distFn kmeans.DistanceFunction) {
+		newKMeansPlusPlusInitializer(distFn, rand.New(rand.NewSource(1)), 1).InitCentroids(gonumVectors, k)
+	})
+	parallel := passes(func(distFn kmeans.DistanceFunction) {
+		newKMeansParallelInitializer(distFn, rand.New(rand.NewSource(1)),
+			DefaultKMeansParallelRounds, DefaultKMeansParallelOversampling, 1).InitCentroids(gonumVectors, k)
+	})
+
+	if plusPlus != k-1 {
+		t.Errorf("kmeans++ passes got = %v, want %v", plusPlus, k-1)
+	}
+	if parallel > DefaultKMeansParallelRounds+1 {
+		t.Errorf("k-means|| passes got = %v, want <= %v", parallel, DefaultKMeansParallelRounds+1)
+	}
+}
+
+func TestKMeansParallel_InitCentroids_FewCandidates(t *testing.T) {
+	vectors := [][]float64{{1}, {2}, {10}, {11}, {20}, {21}}
+	gonumVectors, _ := moarray.ToGonumVectors[float64](vectors...)
+
+	// with a tiny oversampling factor, the rounds sample (almost) no candidates.
+	r := NewKMeansParallelInitializer(L2Distance, 1, 0.001)
+	if got := r.InitCentroids(gonumVectors, 4); len(got) != 4 {
+		t.Errorf("InitCentroids() returned %v centroids, want %v", len(got), 4)
+	}
+}
+
+func TestElkanClusterer_Cluster_KMeansParallel(t *testing.T) {
+	vectorList := [][]float64{
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+	}
+	ekm, err := NewElkanClusterer(vectorList, 2, WithInit(kmeans.KmeansParallel), WithKMeansParallelConfig(2, 1))
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	if _, err = ekm.Cluster(); err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+	if !assertx.InEpsilonF64(12, ekm.SSE()) {
+		t.Errorf("SSE() got = %v, want %v", ekm.SSE(), 12)
+	}
+}
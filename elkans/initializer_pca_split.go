@@ -0,0 +1,186 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"sort"
+)
+
+const (
+	// pcaSplitPowerIterations is the max number of power iterations used to find a principal direction.
+	pcaSplitPowerIterations = 30
+	// pcaSplitTolerance stops the power iterations once the direction no longer changes.
+	pcaSplitTolerance = 1e-10
+)
+
+var _ Initializer = (*PCASplit)(nil)
+
+// PCASplit initializes the centroids using Principal Direction Divisive Partitioning (PDDP).
+// Starting from a single cluster of all the vectors, it repeatedly splits the cluster with the largest SSE
+// into two, by the sign of the projection of its vectors on its principal direction, until there are k
+// clusters. The centroids are the cluster means. It does not draw random numbers, and the splits are based
+// on the Euclidean scatter of the vectors, whatever the distance function of the clusterer.
+// Complexity: O(n*d*log(k)) per power iteration for balanced splits; d = dimension of the vectors
+// Ref Paper: https://doi.org/10.1023/A:1009740529316
+type PCASplit struct {
+}
+
+func NewPCASplitInitializer() Initializer {
+	return &PCASplit{}
+}
+
+// pcaCluster is a cluster of the PCASplit initializer.
+type pcaCluster struct {
+	members []int
+	mean    *mat.VecDense
+	sse     float64
+}
+
+func (ps *PCASplit) InitCentroids(vectors []*mat.VecDense, k int) (centroids []*mat.VecDense) {
+	all := make([]int, len(vectors))
+	for i := range all {
+		all[i] = i
+	}
+	clusters := []pcaCluster{newPCACluster(vectors, all)}
+
+	for len(clusters) < k {
+		// pick the cluster with the largest SSE. A cluster with a zero SSE only has identical vectors,
+		// so it cannot be split.
+		splitIdx := -1
+		for i := range clusters {
+			if clusters[i].sse > 0 && (splitIdx == -1 || clusters[i].sse > clusters[splitIdx].sse) {
+				splitIdx = i
+			}
+		}
+		if splitIdx == -1 {
+			break
+		}
+
+		left, right := ps.split(vectors, clusters[splitIdx])
+		clusters[splitIdx] = newPCACluster(vectors, left)
+		clusters = append(clusters, newPCACluster(vectors, right))
+	}
+
+	centroids = make([]*mat.VecDense, k)
+	for c := range centroids {
+		// less than k distinct vectors: fill up with copies of the existing centroids.
+		centroids[c] = mat.VecDenseCopyOf(clusters[c%len(clusters)].mean)
+	}
+	return centroids
+}
+
+// split divides the members of cluster by the sign of their projection on its principal direction.
+func (ps *PCASplit) split(vectors []*mat.VecDense, cluster pcaCluster) (left, right []int) {
+	direction := principalDirection(vectors, cluster)
+	offset := mat.Dot(cluster.mean, direction)
+
+	projections := make([]float64, len(cluster.members))
+	for i, x := range cluster.members {
+		projections[i] = mat.Dot(vectors[x], direction) - offset
+		if projections[i] < 0 {
+			left = append(left, x)
+		} else {
+			right = append(right, x)
+		}
+	}
+	if len(left) > 0 && len(right) > 0 {
+		return left, right
+	}
+
+	// float rounding put all the members on one side, so split at the median projection instead.
+	order := make([]int, len(cluster.members))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return projections[order[i]] < projections[order[j]]
+	})
+	half := len(order) / 2
+	left, right = make([]int, 0, half), make([]int, 0, len(order)-half)
+	for i, o := range order {
+		if i < half {
+			left = append(left, cluster.members[o])
+		} else {
+			right = append(right, cluster.members[o])
+		}
+	}
+	return left, right
+}
+
+// principalDirection returns the unit eigenvector of the largest eigenvalue of the covariance matrix of the
+// cluster, using power iterations on the centered vectors so that the d*d covariance matrix is never built.
+// The iterations start from the direction of the member farthest from the mean, which is a good guess and
+// does not depend on random numbers.
+func principalDirection(vectors []*mat.VecDense, cluster pcaCluster) *mat.VecDense {
+	dim := cluster.mean.Len()
+	direction := mat.NewVecDense(dim, nil)
+	farthest, maxDist := cluster.members[0], 0.0
+	for _, x := range cluster.members {
+		direction.SubVec(vectors[x], cluster.mean)
+		if dist := mat.Norm(direction, 2); dist > maxDist {
+			farthest, maxDist = x, dist
+		}
+	}
+	direction.SubVec(vectors[farthest], cluster.mean)
+	direction.ScaleVec(1/maxDist, direction)
+
+	next := mat.NewVecDense(dim, nil)
+	for iter := 0; iter < pcaSplitPowerIterations; iter++ {
+		// next = sum((x - mean) * dot(x - mean, direction))
+		offset := mat.Dot(cluster.mean, direction)
+		next.Zero()
+		var sumProjections float64
+		for _, x := range cluster.members {
+			projection := mat.Dot(vectors[x], direction) - offset
+			next.AddScaledVec(next, projection, vectors[x])
+			sumProjections += projection
+		}
+		next.AddScaledVec(next, -sumProjections, cluster.mean)
+
+		norm := mat.Norm(next, 2)
+		if norm == 0 {
+			break
+		}
+		next.ScaleVec(1/norm, next)
+		converged := 1-math.Abs(mat.Dot(next, direction)) < pcaSplitTolerance
+		direction.CopyVec(next)
+		if converged {
+			break
+		}
+	}
+	return direction
+}
+
+func newPCACluster(vectors []*mat.VecDense, members []int) pcaCluster {
+	mean := mat.NewVecDense(vectors[0].Len(), nil)
+	for _, x := range members {
+		mean.AddVec(mean, vectors[x])
+	}
+	mean.ScaleVec(1/float64(len(members)), mean)
+
+	diff := mat.NewVecDense(mean.Len(), nil)
+	var sse float64
+	for _, x := range members {
+		diff.SubVec(vectors[x], mean)
+		sse += mat.Dot(diff, diff)
+	}
+	return pcaCluster{
+		members: members,
+		mean:    mean,
+		sse:     sse,
+	}
+}
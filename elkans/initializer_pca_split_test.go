@@ -0,0 +1,101 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"github.com/arjunsk/kmeans/utils/moarray"
+	"reflect"
+	"testing"
+)
+
+func TestPCASplit_InitCentroids(t *testing.T) {
+	tests := []struct {
+		name    string
+		vectors [][]float64
+		k       int
+		want    [][]float64
+	}{
+		{
+			name: "Test 1 - largest SSE cluster is split first",
+			// the first split is at the mean 13.83, and the left cluster {0, 1, 10, 11} has the largest SSE.
+			vectors: [][]float64{{0}, {1}, {10}, {11}, {30}, {31}},
+			k:       3,
+			want:    [][]float64{{10.5}, {30.5}, {0.5}},
+		},
+		{
+			name: "Test 2 - split along the principal direction",
+			// the groups only differ along the diagonal.
+			vectors: [][]float64{{0, 0}, {1, 1}, {0, 1}, {1, 0}, {10, 10}, {11, 11}, {10, 11}, {11, 10}},
+			k:       2,
+			want:    [][]float64{{10.5, 10.5}, {0.5, 0.5}},
+		},
+		{
+			name:    "Test 3 - less distinct vectors than k",
+			vectors: [][]float64{{1}, {1}, {2}},
+			k:       3,
+			want:    [][]float64{{1}, {2}, {1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gonumVectors, _ := moarray.ToGonumVectors[float64](tt.vectors...)
+			got := moarray.ToMoArrays[float64](NewPCASplitInitializer().InitCentroids(gonumVectors, tt.k))
+			if !assertx.InEpsilonF64Slices(tt.want, got) {
+				t.Errorf("InitCentroids() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPCASplit_InitCentroids_Deterministic(t *testing.T) {
+	data := make([][]float64, 1000)
+	populateRandData(1000, 16, data)
+	gonumVectors, _ := moarray.ToGonumVectors[float64](data...)
+
+	want := NewPCASplitInitializer().InitCentroids(gonumVectors, 20)
+	got := NewPCASplitInitializer().InitCentroids(gonumVectors, 20)
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("InitCentroids() is not deterministic")
+	}
+}
+
+func TestElkanClusterer_Cluster_PCASplit(t *testing.T) {
+	vectorList := [][]float64{
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+	}
+	ekm, err := NewElkanClusterer(vectorList, 2, WithInit(kmeans.PCASplit))
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	if _, err = ekm.Cluster(); err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+	if !assertx.InEpsilonF64(12, ekm.SSE()) {
+		t.Errorf("SSE() got = %v, want %v", ekm.SSE(), 12)
+	}
+}
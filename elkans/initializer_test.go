@@ -16,6 +16,7 @@ package elkans
 
 import (
 	"github.com/arjunsk/kmeans/utils/moarray"
+	"math/rand"
 	"reflect"
 	"testing"
 )
@@ -53,7 +54,7 @@ func TestRandom_InitCentroids(t *testing.T) {
 				// NOTE: values of random initialization need not be farther apart, it is random.
 				// NOTE: we get the same random values in the test case because we are using a constant seed value.
 				{1, 2, 4, 5},
-				{1, 2, 3, 4},
+				{10, 2, 4, 5},
 			},
 		},
 	}
@@ -68,6 +69,81 @@ func TestRandom_InitCentroids(t *testing.T) {
 	}
 }
 
+func TestRandom_InitCentroids_Distinct(t *testing.T) {
+	type args struct {
+		vectors [][]float64
+		k       int
+	}
+	tests := []struct {
+		name          string
+		args          args
+		wantDistinct  int
+		wantCentroids int
+	}{
+		{
+			name: "Test 1 - Duplicates are skipped",
+			args: args{
+				vectors: [][]float64{
+					{1, 2}, {1, 2}, {1, 2}, {1, 2}, {1, 2}, {1, 2}, {1, 2}, {1, 2},
+					{3, 4}, {5, 6}, {7, 8},
+				},
+				k: 4,
+			},
+			wantDistinct:  4,
+			wantCentroids: 4,
+		},
+		{
+			name: "Test 2 - Less distinct vectors than k",
+			args: args{
+				vectors: [][]float64{
+					{1, 2}, {1, 2}, {1, 2}, {3, 4},
+				},
+				k: 3,
+			},
+			wantDistinct:  2,
+			wantCentroids: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gonumVectors, _ := moarray.ToGonumVectors[float64](tt.args.vectors...)
+			for seed := int64(1); seed <= 20; seed++ {
+				r := NewRandomInitializerWithRand(rand.New(rand.NewSource(seed)))
+				got := moarray.ToMoArrays[float64](r.InitCentroids(gonumVectors, tt.args.k))
+				distinct := make(map[[2]float64]bool)
+				for _, centroid := range got {
+					distinct[[2]float64{centroid[0], centroid[1]}] = true
+				}
+				if len(got) != tt.wantCentroids || len(distinct) != tt.wantDistinct {
+					t.Errorf("seed=%v: InitCentroids() = %v, want %v centroids with %v distinct",
+						seed, got, tt.wantCentroids, tt.wantDistinct)
+				}
+			}
+		})
+	}
+}
+
+func TestRandomPartition_InitCentroids(t *testing.T) {
+	vectors := [][]float64{{0, 0}, {2, 2}, {4, 4}, {6, 6}, {8, 8}, {10, 10}}
+	gonumVectors, _ := moarray.ToGonumVectors[float64](vectors...)
+
+	got := moarray.ToMoArrays[float64](NewRandomPartitionInitializer().InitCentroids(gonumVectors, 2))
+	if len(got) != 2 {
+		t.Fatalf("InitCentroids() returned %v centroids, want %v", len(got), 2)
+	}
+	// every centroid is the mean of a partition, so it lies within the hull of the vectors.
+	for _, centroid := range got {
+		if centroid[0] != centroid[1] || centroid[0] < 0 || centroid[0] > 10 {
+			t.Errorf("InitCentroids() = %v, want means of the vectors", got)
+		}
+	}
+
+	// the input vectors are not modified.
+	if want, _ := moarray.ToGonumVectors[float64](vectors...); !reflect.DeepEqual(want, gonumVectors) {
+		t.Errorf("InitCentroids() modified the input vectors")
+	}
+}
+
 func TestKMeansPlusPlus_InitCentroids(t *testing.T) {
 	type args struct {
 		vectors [][]float64
@@ -115,6 +191,55 @@ func TestKMeansPlusPlus_InitCentroids(t *testing.T) {
 	}
 }
 
+func TestKMeansPlusPlus_InitCentroids_Exact(t *testing.T) {
+	// 3 well separated groups. Since D(x)^2 is measured against all the chosen centers,
+	// every group gets exactly one of the 3 initial centroids.
+	vectors := [][]float64{
+		{0, 0}, {0, 1}, {1, 0}, {1, 1},
+		{100, 100}, {100, 101}, {101, 100}, {101, 101},
+		{0, 100}, {0, 101}, {1, 100}, {1, 101},
+	}
+	group := func(v []float64) int {
+		return int(v[0]/50) + 2*int(v[1]/50)
+	}
+
+	gonumVectors, _ := moarray.ToGonumVectors[float64](vectors...)
+	for seed := int64(1); seed <= 20; seed++ {
+		r := NewKMeansPlusPlusInitializerWithRand(L2Distance, rand.New(rand.NewSource(seed)))
+		got := moarray.ToMoArrays[float64](r.InitCentroids(gonumVectors, 3))
+		seen := make(map[int]bool)
+		for _, centroid := range got {
+			seen[group(centroid)] = true
+		}
+		if len(seen) != 3 {
+			t.Errorf("seed=%v: InitCentroids() = %v, want one centroid per group", seed, got)
+		}
+	}
+}
+
+func TestKMeansPlusPlus_InitCentroids_Workers(t *testing.T) {
+	data := make([][]float64, 1000)
+	populateRandData(1000, 16, data)
+	gonumVectors, _ := moarray.ToGonumVectors[float64](data...)
+
+	want := newKMeansPlusPlusInitializer(L2Distance, rand.New(rand.NewSource(7)), 1).InitCentroids(gonumVectors, 20)
+	for _, workers := range []int{0, 3, 8} {
+		got := newKMeansPlusPlusInitializer(L2Distance, rand.New(rand.NewSource(7)), workers).InitCentroids(gonumVectors, 20)
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("workers=%v: InitCentroids() differs from single worker", workers)
+		}
+	}
+}
+
+func TestKMeansPlusPlus_InitCentroids_Duplicates(t *testing.T) {
+	vectors := [][]float64{{1, 1}, {1, 1}, {1, 1}, {1, 1}}
+	gonumVectors, _ := moarray.ToGonumVectors[float64](vectors...)
+	got := NewKMeansPlusPlusInitializer(L2Distance).InitCentroids(gonumVectors, 3)
+	if want := [][]float64{{1, 1}, {1, 1}, {1, 1}}; !reflect.DeepEqual(moarray.ToMoArrays[float64](got), want) {
+		t.Errorf("InitCentroids() = %v, want %v", moarray.ToMoArrays[float64](got), want)
+	}
+}
+
 /*
 date : 2023-11-20
 goos: darwin
@@ -135,7 +260,11 @@ func Benchmark_InitCentroids(b *testing.B) {
 	populateRandData(rowCnt, dims, data)
 
 	random := NewRandomInitializer()
+	randomPartition := NewRandomPartitionInitializer()
 	kmeanspp := NewKMeansPlusPlusInitializer(L2Distance)
+	kmeansParallel := NewKMeansParallelInitializer(L2Distance, DefaultKMeansParallelRounds, DefaultKMeansParallelOversampling)
+	farthestFirst := NewFarthestFirstInitializer(L2Distance)
+	pcaSplit := NewPCASplitInitializer()
 
 	b.Run("RANDOM", func(b *testing.B) {
 		b.ResetTimer()
@@ -145,6 +274,14 @@ func Benchmark_InitCentroids(b *testing.B) {
 		}
 	})
 
+	b.Run("RANDOM_PARTITION", func(b *testing.B) {
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			gonumVectors, _ := moarray.ToGonumVectors[float64](data...)
+			_ = randomPartition.InitCentroids(gonumVectors, k)
+		}
+	})
+
 	b.Run("KMEANS++", func(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
@@ -152,4 +289,28 @@ func Benchmark_InitCentroids(b *testing.B) {
 			_ = kmeanspp.InitCentroids(gonumVectors, k)
 		}
 	})
+
+	b.Run("KMEANS||", func(b *testing.B) {
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			gonumVectors, _ := moarray.ToGonumVectors[float64](data...)
+			_ = kmeansParallel.InitCentroids(gonumVectors, k)
+		}
+	})
+
+	b.Run("FARTHEST_FIRST", func(b *testing.B) {
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			gonumVectors, _ := moarray.ToGonumVectors[float64](data...)
+			_ = farthestFirst.InitCentroids(gonumVectors, k)
+		}
+	})
+
+	b.Run("PCA_SPLIT", func(b *testing.B) {
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			gonumVectors, _ := moarray.ToGonumVectors[float64](data...)
+			_ = pcaSplit.InitCentroids(gonumVectors, k)
+		}
+	})
 }
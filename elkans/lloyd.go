@@ -0,0 +1,137 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"context"
+	"github.com/arjunsk/kmeans"
+	"math"
+	"sync/atomic"
+)
+
+// LloydClusterer is the standard kmeans algorithm: every iteration computes the distance of every vector to
+// every centroid, and moves the centroids to the means of their clusters. It takes O(n*k) distance
+// computations per iteration and no memory besides the assignments, which suits a small k. It is also the
+// reference for the algorithms that skip distance computations, as they must give the same assignments.
+//
+// Ref Paper: https://doi.org/10.1109/TIT.1982.1056489
+type LloydClusterer struct {
+	clusterer
+}
+
+var _ kmeans.Clusterer = new(LloydClusterer)
+
+// NewLloydClusterer returns a LloydClusterer for clustering vectors into clusterCnt clusters.
+// The defaults can be overridden using the With* options.
+func NewLloydClusterer(vectors [][]float64, clusterCnt int, opts ...Option) (*LloydClusterer, error) {
+	base, err := newClusterer(vectors, clusterCnt, newOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	km := &LloydClusterer{
+		clusterer: base,
+	}
+	km.algo = km
+	return km, nil
+}
+
+func (km *LloydClusterer) base() *clusterer {
+	return &km.clusterer
+}
+
+// newRun returns a single-run copy of km, seeded with seed and with its own centroids.
+func (km *LloydClusterer) newRun(seed int64) algorithm {
+	run := &LloydClusterer{
+		clusterer: km.clusterer.newRun(seed),
+	}
+	run.algo = run
+	return run
+}
+
+// iterate runs Lloyd's kmeans from the initial centroids.
+func (km *LloydClusterer) iterate(ctx context.Context) error {
+	if km.skipIterations() {
+		_, err := km.assignData(ctx, true)
+		return err
+	}
+
+	for iter := 0; ; iter++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		iterStart := km.startIteration()
+
+		changes, err := km.assignData(ctx, iter == 0)
+		if err != nil {
+			return err
+		}
+
+		newCentroids := km.recalculateCentroids()
+
+		_, maxShift := km.centroidShifts(newCentroids)
+
+		km.centroids = newCentroids
+		km.iterations = iter + 1
+		km.recordIteration(changes, maxShift, iterStart)
+
+		if km.isConverged(iter, changes, maxShift) {
+			break
+		}
+	}
+	return nil
+}
+
+// assignData assigns each vector to the nearest centroid and returns the number of vectors that changed their
+// cluster. If first is set, the vectors have no assignment yet, so it returns 0.
+func (km *LloydClusterer) assignData(ctx context.Context, first bool) (int, error) {
+	var changes int64
+
+	parallelFor(km.vectorCnt, km.workers, func(start, end int) {
+		var chunkChanges int64
+		for x := start; x < end; x++ {
+			if (x-start)%ctxCheckInterval == 0 && ctx.Err() != nil {
+				return
+			}
+
+			// like in ElkanClusterer, another centroid only replaces the current one if it is strictly closer,
+			// and ties between the other centroids go to the lowest index.
+			current := km.assignments[x]
+			closest, minDist := -1, math.MaxFloat64
+			if !first {
+				closest, minDist = current, km.distFn(km.vectorList[x], km.centroids[current])
+			}
+			for c := range km.centroids {
+				if !first && c == current {
+					continue
+				}
+				if dist := km.distFn(km.vectorList[x], km.centroids[c]); dist < minDist {
+					closest, minDist = c, dist
+				}
+			}
+
+			km.assignments[x] = closest
+			if !first && closest != current {
+				chunkChanges++
+			}
+		}
+		atomic.AddInt64(&changes, chunkChanges)
+	})
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return int(changes), nil
+}
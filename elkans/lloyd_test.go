@@ -0,0 +1,130 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"fmt"
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"reflect"
+	"testing"
+)
+
+func TestLloydClusterer_Cluster(t *testing.T) {
+	vectorList := [][]float64{
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+	}
+	km, err := NewLloydClusterer(vectorList, 2, WithInit(kmeans.Random))
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	got, err := km.Cluster()
+	if err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+	want := [][]float64{
+		{1, 2, 3.6666666666666665, 4.666666666666666},
+		{10, 3.333333333333333, 4, 5},
+	}
+	if !assertx.InEpsilonF64Slices(want, got) {
+		t.Errorf("Cluster() got = %v, want %v", got, want)
+	}
+	if !assertx.InEpsilonF64(12, km.SSE()) {
+		t.Errorf("SSE() got = %v, want %v", km.SSE(), 12)
+	}
+}
+
+// TestLloydClusterer_Oracle checks that the algorithms skipping distance computations with the triangle
+// inequality give the same assignments, centroids and iterations as Lloyd's algorithm on the same seeds.
+func TestLloydClusterer_Oracle(t *testing.T) {
+	data := make([][]float64, 400)
+	populateRandData(400, 6, data)
+
+	algorithms := []kmeans.Algorithm{kmeans.Elkan, kmeans.Hamerly, kmeans.Yinyang}
+	configs := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "L2", opts: nil},
+		{name: "Spherical", opts: []Option{WithDistance(kmeans.InnerProduct), WithNormalize(true)}},
+		{name: "CentroidShift", opts: []Option{WithConvergence(kmeans.CentroidShift), WithTolerance(0.001)}},
+	}
+	inits := []kmeans.InitType{kmeans.Random, kmeans.KmeansPlusPlus, kmeans.KmeansParallel, kmeans.RandomPartition}
+
+	for _, cfg := range configs {
+		for _, initType := range inits {
+			for seed := int64(1); seed <= 3; seed++ {
+				opts := append([]Option{WithInit(initType), WithSeed(seed), WithYinyangGroups(3)}, cfg.opts...)
+				name := fmt.Sprintf("%s/init=%d/seed=%d", cfg.name, initType, seed)
+
+				checkSameAsLloyd(t, name, data, 12, opts, algorithms)
+			}
+		}
+	}
+
+	// [5 3] becomes as close to centroids 2 and 3 after they move. With 2 groups, Yinyang visits the group
+	// of centroid 3 first, but the tie must still go to the lowest index, like in Lloyd's algorithm.
+	tieData := [][]float64{{6, 2}, {3, 6}, {0, 1}, {0, 5}, {3, 0}, {6, 4}, {0, 1}, {5, 3}, {0, 1}, {1, 3}}
+	tieCentroids := [][]float64{{1, 5}, {3, 1}, {6, 0}, {6, 6}}
+	checkSameAsLloyd(t, "Ties", tieData, 4,
+		[]Option{WithInitialCentroids(tieCentroids), WithYinyangGroups(2)}, algorithms)
+}
+
+// checkSameAsLloyd checks that the algorithms give the same labels, centroids and iterations as Lloyd's
+// algorithm with opts.
+func checkSameAsLloyd(t *testing.T, name string, data [][]float64, k int, opts []Option, algorithms []kmeans.Algorithm) {
+	t.Helper()
+	lloyd, err := NewLloydClusterer(data, k, opts...)
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	if _, err = lloyd.Cluster(); err != nil {
+		t.Fatalf("%s: Cluster() error = %v", name, err)
+	}
+	want := lloyd.Result()
+
+	for _, algorithm := range algorithms {
+		km, err := NewClusterer(data, k, append(opts, WithAlgorithm(algorithm))...)
+		if err != nil {
+			t.Fatalf("Error while creating KMeans object %v", err)
+		}
+		if _, err = km.Cluster(); err != nil {
+			t.Fatalf("%s: Cluster() error = %v", name, err)
+		}
+		got := km.(interface{ Result() *kmeans.ClusterResult }).Result()
+
+		if !reflect.DeepEqual(want.Labels, got.Labels) {
+			t.Errorf("%s: algorithm=%d: Labels differ from Lloyd", name, algorithm)
+		}
+		if !assertx.InEpsilonF64Slices(want.Centroids, got.Centroids) {
+			t.Errorf("%s: algorithm=%d: Centroids differ from Lloyd", name, algorithm)
+		}
+		if want.Iterations != got.Iterations {
+			t.Errorf("%s: algorithm=%d: Iterations got = %v, want %v",
+				name, algorithm, got.Iterations, want.Iterations)
+		}
+	}
+}
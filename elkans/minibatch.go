@@ -0,0 +1,219 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"context"
+	"github.com/arjunsk/kmeans"
+	"gonum.org/v1/gonum/mat"
+	"math"
+)
+
+const (
+	// DefaultMiniBatchSize is the default number of vectors sampled per mini-batch.
+	DefaultMiniBatchSize = 1024
+	// DefaultMiniBatchMaxNoImprovement is the default number of mini-batches without improvement of the
+	// smoothed inertia after which MiniBatchClusterer stops.
+	DefaultMiniBatchMaxNoImprovement = 10
+)
+
+// MiniBatchClusterer is Sculley's mini-batch kmeans. Every iteration samples a batch of vectors uniformly at
+// random (with replacement), assigns them to their closest centroid, and moves each centroid towards its
+// batch vectors with a per-centroid learning rate of 1/n(c), n(c) being the number of vectors the centroid
+// has been updated with so far. The centroids are thus running means of the vectors assigned to them.
+//
+// An iteration takes O(b*k) distance computations instead of O(n*k), so maxIterations is a number of
+// mini-batches. The clustering stops when:
+//   - the smoothed inertia (an exponentially weighted average of the mean squared distance of the batch
+//     vectors) has not improved for maxNoImprovement batches (see WithMaxNoImprovement).
+//   - the largest centroid shift of a batch is below deltaThreshold, if kmeans.CentroidShift is selected.
+//   - maxIterations batches were processed.
+//
+// The vectors are then assigned once to the final centroids, for Result and SSE. A centroid that no batch
+// vector was ever assigned to keeps its initial position, so the EmptyClusterPolicy is not used.
+//
+// Ref Paper: https://doi.org/10.1145/1772690.1772862
+type MiniBatchClusterer struct {
+	clusterer
+
+	batchSize        int
+	maxNoImprovement int
+}
+
+var _ kmeans.Clusterer = new(MiniBatchClusterer)
+
+// NewMiniBatchClusterer returns a MiniBatchClusterer for clustering vectors into clusterCnt clusters.
+// The batch size is set with WithBatchSize, and the early stopping with WithMaxNoImprovement.
+// The defaults can be overridden using the With* options.
+func NewMiniBatchClusterer(vectors [][]float64, clusterCnt int, opts ...Option) (*MiniBatchClusterer, error) {
+	cfg := newOptions(opts)
+	base, err := newClusterer(vectors, clusterCnt, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	batchSize := cfg.batchSize
+	if batchSize > len(vectors) {
+		batchSize = len(vectors)
+	}
+
+	km := &MiniBatchClusterer{
+		clusterer:        base,
+		batchSize:        batchSize,
+		maxNoImprovement: cfg.maxNoImprovement,
+	}
+	km.algo = km
+	return km, nil
+}
+
+func (km *MiniBatchClusterer) base() *clusterer {
+	return &km.clusterer
+}
+
+// newRun returns a single-run copy of km, seeded with seed and with its own centroids.
+func (km *MiniBatchClusterer) newRun(seed int64) algorithm {
+	run := &MiniBatchClusterer{
+		clusterer:        km.clusterer.newRun(seed),
+		batchSize:        km.batchSize,
+		maxNoImprovement: km.maxNoImprovement,
+	}
+	run.algo = run
+	return run
+}
+
+// iterate runs mini-batch kmeans from the initial centroids.
+func (km *MiniBatchClusterer) iterate(ctx context.Context) error {
+	// the centroids are updated in place, and the initializers may return the input vectors themselves.
+	for c := range km.centroids {
+		km.centroids[c] = mat.VecDenseCopyOf(km.centroids[c])
+	}
+
+	// the smoothing factor makes the average span about n/b batches, i.e. one pass over the vectors.
+	alpha := 2 * float64(km.batchSize) / float64(km.vectorCnt+1)
+	if alpha > 1 {
+		alpha = 1
+	}
+
+	batch := make([]int, km.batchSize)
+	batchAssignments := make([]int, km.batchSize)
+	batchDistances := make([]float64, km.batchSize)
+	updateCounts := make([]int64, km.clusterCnt)
+
+	var smoothedInertia, bestInertia float64
+	noImprovement := 0
+	km.convergenceReason = kmeans.MaxIterationsReached
+	for iter := 0; iter < km.maxIterations; iter++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		for i := range batch {
+			batch[i] = km.rand.Intn(km.vectorCnt)
+		}
+		km.assignBatch(batch, batchAssignments, batchDistances)
+
+		var inertia float64
+		for _, dist := range batchDistances {
+			inertia += dist * dist
+		}
+		inertia /= float64(km.batchSize)
+
+		maxShift := km.updateCentroids(batch, batchAssignments, updateCounts)
+		km.iterations = iter + 1
+
+		if iter == 0 {
+			smoothedInertia, bestInertia = inertia, inertia
+			continue
+		}
+
+		smoothedInertia = (1-alpha)*smoothedInertia + alpha*inertia
+		if smoothedInertia < bestInertia {
+			bestInertia = smoothedInertia
+			noImprovement = 0
+		} else {
+			noImprovement++
+		}
+
+		if km.maxNoImprovement > 0 && noImprovement >= km.maxNoImprovement {
+			km.convergenceReason = kmeans.InertiaNotImproving
+			break
+		}
+		if km.convergenceType == kmeans.CentroidShift && maxShift < km.deltaThreshold {
+			km.convergenceReason = kmeans.CentroidShiftBelowThreshold
+			break
+		}
+	}
+
+	return km.assignData(ctx)
+}
+
+// assignBatch sets the closest centroid of each batch vector, and the distance to it.
+// Ties go to the lowest index, so that the result does not depend on the number of workers.
+func (km *MiniBatchClusterer) assignBatch(batch []int, assignments []int, distances []float64) {
+	parallelFor(len(batch), km.workers, func(start, end int) {
+		for i := start; i < end; i++ {
+			assignments[i], distances[i] = km.closest(km.vectorList[batch[i]])
+		}
+	})
+}
+
+// updateCentroids moves the centroids towards their batch vectors, one vector at a time, with the learning
+// rate 1/n(c). It returns the largest distance moved by a centroid.
+func (km *MiniBatchClusterer) updateCentroids(batch []int, assignments []int, updateCounts []int64) (maxShift float64) {
+	// only the centroids with batch vectors move, so only their previous position is kept.
+	previous := make(map[int]*mat.VecDense)
+	for _, c := range assignments {
+		if _, ok := previous[c]; !ok {
+			previous[c] = mat.VecDenseCopyOf(km.centroids[c])
+		}
+	}
+
+	for i, x := range batch {
+		c := assignments[i]
+		updateCounts[c]++
+		eta := 1 / float64(updateCounts[c])
+		km.centroids[c].ScaleVec(1-eta, km.centroids[c])
+		km.centroids[c].AddScaledVec(km.centroids[c], eta, km.vectorList[x])
+	}
+
+	for c, prev := range previous {
+		maxShift = math.Max(maxShift, km.distFn(prev, km.centroids[c]))
+	}
+	return maxShift
+}
+
+// assignData assigns each vector to its closest centroid.
+func (km *MiniBatchClusterer) assignData(ctx context.Context) error {
+	parallelFor(km.vectorCnt, km.workers, func(start, end int) {
+		for x := start; x < end; x++ {
+			if (x-start)%ctxCheckInterval == 0 && ctx.Err() != nil {
+				return
+			}
+			km.assignments[x], _ = km.closest(km.vectorList[x])
+		}
+	})
+	return ctx.Err()
+}
+
+// closest returns the index of the centroid closest to v, the lowest one on ties, and the distance to it.
+func (km *MiniBatchClusterer) closest(v *mat.VecDense) (int, float64) {
+	closest, minDist := 0, math.MaxFloat64
+	for c := range km.centroids {
+		if dist := km.distFn(v, km.centroids[c]); dist < minDist {
+			closest, minDist = c, dist
+		}
+	}
+	return closest, minDist
+}
@@ -0,0 +1,213 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// blobs returns n vectors drawn around k well separated centers.
+func blobs(n, k, dim int, seed int64) [][]float64 {
+	random := rand.New(rand.NewSource(seed))
+	vectors := make([][]float64, n)
+	for i := range vectors {
+		vectors[i] = make([]float64, dim)
+		for d := range vectors[i] {
+			vectors[i][d] = float64((i%k)*100) + random.NormFloat64()
+		}
+	}
+	return vectors
+}
+
+func TestMiniBatchClusterer_Cluster(t *testing.T) {
+	vectorList := [][]float64{
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+	}
+	km, err := NewMiniBatchClusterer(vectorList, 2, WithBatchSize(4))
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	if _, err = km.Cluster(); err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+	labels := km.Result().Labels
+	for i := range labels {
+		if (labels[i] == labels[0]) != (i < 6) {
+			t.Fatalf("Result().Labels got = %v, want the first and last 6 vectors in two clusters", labels)
+		}
+	}
+	// Lloyd's SSE is 12. The centroids are running means of sampled vectors, so they are close but not exact.
+	if sse := km.SSE(); sse < 12 || sse > 12*1.1 {
+		t.Errorf("SSE() got = %v, want within 10%% of %v", sse, 12)
+	}
+}
+
+func TestMiniBatchClusterer_SSE(t *testing.T) {
+	data := blobs(5000, 8, 16, 1)
+
+	lloyd, err := NewLloydClusterer(data, 8)
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	if _, err = lloyd.Cluster(); err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+
+	km, err := NewMiniBatchClusterer(data, 8, WithBatchSize(256))
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	if _, err = km.Cluster(); err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+	if km.SSE() > lloyd.SSE()*1.05 {
+		t.Errorf("SSE() got = %v, want within 5%% of Lloyd's %v", km.SSE(), lloyd.SSE())
+	}
+}
+
+func TestMiniBatchClusterer_Convergence(t *testing.T) {
+	data := blobs(2000, 4, 8, 2)
+	tests := []struct {
+		name           string
+		opts           []Option
+		wantReason     kmeans.ConvergenceReason
+		wantIterations int
+	}{
+		{
+			name:       "Test 1 - smoothed inertia not improving",
+			opts:       []Option{WithMaxNoImprovement(3)},
+			wantReason: kmeans.InertiaNotImproving,
+		},
+		{
+			name:       "Test 2 - centroid shift",
+			opts:       []Option{WithMaxNoImprovement(0), WithConvergence(kmeans.CentroidShift), WithTolerance(0.5)},
+			wantReason: kmeans.CentroidShiftBelowThreshold,
+		},
+		{
+			name:           "Test 3 - max iterations",
+			opts:           []Option{WithMaxNoImprovement(0), WithMaxIter(20)},
+			wantReason:     kmeans.MaxIterationsReached,
+			wantIterations: 20,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, err := NewMiniBatchClusterer(data, 4, append([]Option{WithBatchSize(64)}, tt.opts...)...)
+			if err != nil {
+				t.Fatalf("Error while creating KMeans object %v", err)
+			}
+			if _, err = km.Cluster(); err != nil {
+				t.Fatalf("Cluster() error = %v", err)
+			}
+			result := km.Result()
+			if result.ConvergenceReason != tt.wantReason {
+				t.Errorf("Result().ConvergenceReason got = %v, want %v", result.ConvergenceReason, tt.wantReason)
+			}
+			if tt.wantIterations != 0 && result.Iterations != tt.wantIterations {
+				t.Errorf("Result().Iterations got = %v, want %v", result.Iterations, tt.wantIterations)
+			}
+			if result.Iterations >= kmeans.DefaultMaxIterations {
+				t.Errorf("Result().Iterations got = %v, want less than %v", result.Iterations, kmeans.DefaultMaxIterations)
+			}
+		})
+	}
+}
+
+func TestMiniBatchClusterer_Workers(t *testing.T) {
+	data := blobs(3000, 6, 8, 3)
+
+	var want *kmeans.ClusterResult
+	for _, workers := range []int{1, 2, 7} {
+		km, err := NewMiniBatchClusterer(data, 6, WithBatchSize(100), WithWorkers(workers))
+		if err != nil {
+			t.Fatalf("Error while creating KMeans object %v", err)
+		}
+		if _, err = km.Cluster(); err != nil {
+			t.Fatalf("Cluster() error = %v", err)
+		}
+		got := km.Result()
+		if want == nil {
+			want = got
+			continue
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("workers=%d: Result() differs from workers=1", workers)
+		}
+	}
+}
+
+func TestNewMiniBatchClusterer(t *testing.T) {
+	vectorList := [][]float64{{1}, {3}, {10}, {12}}
+	tests := []struct {
+		name          string
+		opts          []Option
+		wantBatchSize int
+		wantErr       bool
+	}{
+		{
+			name:          "Test 1 - default batch size capped at the vector count",
+			wantBatchSize: 4,
+		},
+		{
+			name:          "Test 2 - batch size",
+			opts:          []Option{WithBatchSize(3)},
+			wantBatchSize: 3,
+		},
+		{
+			name:    "Test 3 - batch size 0",
+			opts:    []Option{WithBatchSize(0)},
+			wantErr: true,
+		},
+		{
+			name:    "Test 4 - negative max no improvement",
+			opts:    []Option{WithMaxNoImprovement(-1)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, err := NewMiniBatchClusterer(vectorList, 2, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewMiniBatchClusterer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if km.batchSize != tt.wantBatchSize {
+				t.Errorf("NewMiniBatchClusterer() batchSize got = %v, want %v", km.batchSize, tt.wantBatchSize)
+			}
+			if _, err = km.Cluster(); err != nil {
+				t.Fatalf("Cluster() error = %v", err)
+			}
+			if km.SSE() > 4*1.1 {
+				t.Errorf("SSE() got = %v, want within 10%% of %v", km.SSE(), 4)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"context"
+	"github.com/arjunsk/kmeans"
+	moarray2 "github.com/arjunsk/kmeans/utils/moarray"
+	"github.com/arjunsk/kmeans/utils/moerr"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"sync"
+)
+
+// OnlineClusterer is MacQueen's online kmeans. It does not hold the vectors: they are streamed through
+// PartialFit or FitChannel, and each vector moves its closest centroid towards it with the learning rate
+// 1/n(c), n(c) being the number of vectors seen by the centroid. Each centroid is thus the running mean of
+// the vectors assigned to it, and a single scan of a table is enough to train the centroids.
+//
+// The first k distinct vectors become the centroids, unless WithInitialCentroids is set, in which case the
+// initial centroids count as one vector each. The result depends on the order of the vectors.
+// Only the WithDistance, WithNormalize, WithInitialCentroids and WithWorkers options are used.
+// An OnlineClusterer is safe for concurrent use.
+//
+// Ref Paper: https://projecteuclid.org/euclid.bsmsp/1200512992
+type OnlineClusterer struct {
+	mu sync.Mutex
+
+	clusterCnt int
+	distFn     kmeans.DistanceFunction
+	normalize  bool
+	workers    int
+
+	dims         int             // dimension of the vectors, 0 until the first vector is seen.
+	centroids    []*mat.VecDense // grows up to clusterCnt while the first distinct vectors are seen.
+	clusterSizes []int64         // n(c): number of vectors seen by each centroid.
+}
+
+// NewOnlineClusterer returns an OnlineClusterer for clustering a stream of vectors into clusterCnt clusters.
+func NewOnlineClusterer(clusterCnt int, opts ...Option) (*OnlineClusterer, error) {
+	cfg := newOptions(opts)
+	if err := validateOptions(clusterCnt, cfg); err != nil {
+		return nil, err
+	}
+
+	distanceFunction, err := resolveDistanceFn(cfg.distanceType)
+	if err != nil {
+		return nil, err
+	}
+
+	km := &OnlineClusterer{
+		clusterCnt:   clusterCnt,
+		distFn:       distanceFunction,
+		normalize:    cfg.normalize,
+		workers:      cfg.workers,
+		centroids:    make([]*mat.VecDense, 0, clusterCnt),
+		clusterSizes: make([]int64, 0, clusterCnt),
+	}
+
+	if cfg.initialCentroids != nil {
+		km.centroids, err = moarray2.ToGonumVectors[float64](cfg.initialCentroids...)
+		if err != nil {
+			return nil, err
+		}
+		if km.centroids[0].Len() == 0 {
+			return nil, moerr.NewInternalErrorNoCtx("initial centroids is empty")
+		}
+		if km.normalize {
+			moarray2.NormalizeGonumVectors(km.centroids)
+		}
+		km.dims = km.centroids[0].Len()
+		km.clusterSizes = make([]int64, clusterCnt)
+		for c := range km.clusterSizes {
+			km.clusterSizes[c] = 1
+		}
+	}
+	return km, nil
+}
+
+// PartialFit updates the centroids with a batch of vectors, in order. The batch is validated as a whole,
+// so on error the centroids are left unchanged.
+func (km *OnlineClusterer) PartialFit(batch [][]float64) error {
+	vectors, err := km.toGonumVectors(batch)
+	if err != nil {
+		return err
+	}
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	if len(vectors) > 0 {
+		if err = km.checkDims(vectors[0].Len()); err != nil {
+			return err
+		}
+	}
+	for _, v := range vectors {
+		km.update(v)
+	}
+	return nil
+}
+
+// FitChannel updates the centroids with the vectors received from the channel, until it is closed or ctx
+// is done. Vectors with a different dimension are rejected, and the vectors before them are kept.
+func (km *OnlineClusterer) FitChannel(ctx context.Context, vectors <-chan []float64) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case vector, ok := <-vectors:
+			if !ok {
+				return nil
+			}
+			if err := km.PartialFit([][]float64{vector}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Centroids returns the current centroids. It fails until k distinct vectors have been seen.
+func (km *OnlineClusterer) Centroids() ([][]float64, error) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	if len(km.centroids) < km.clusterCnt {
+		return nil, moerr.NewInternalErrorNoCtx("only %d distinct vectors seen, expected at least %d",
+			len(km.centroids), km.clusterCnt)
+	}
+	return moarray2.ToMoArrays[float64](km.centroids), nil
+}
+
+// ClusterSizes returns the number of vectors seen by each centroid.
+func (km *OnlineClusterer) ClusterSizes() []int64 {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	sizes := make([]int64, len(km.clusterSizes))
+	copy(sizes, km.clusterSizes)
+	return sizes
+}
+
+// Assigner returns an Assigner for a snapshot of the current centroids, which is not affected by the
+// next updates. It fails until k distinct vectors have been seen.
+func (km *OnlineClusterer) Assigner() (*Assigner, error) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	if len(km.centroids) < km.clusterCnt {
+		return nil, moerr.NewInternalErrorNoCtx("only %d distinct vectors seen, expected at least %d",
+			len(km.centroids), km.clusterCnt)
+	}
+	centroids := make([]*mat.VecDense, len(km.centroids))
+	for c := range centroids {
+		centroids[c] = mat.VecDenseCopyOf(km.centroids[c])
+	}
+	return newAssigner(centroids, km.distFn, km.normalize, km.workers), nil
+}
+
+// toGonumVectors converts and, if needed, normalizes a batch. It does not touch the state of km.
+func (km *OnlineClusterer) toGonumVectors(batch [][]float64) ([]*mat.VecDense, error) {
+	vectors, err := moarray2.ToGonumVectors[float64](batch...)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) > 0 && vectors[0].Len() == 0 {
+		return nil, moerr.NewInternalErrorNoCtx("input vectors is empty")
+	}
+	if km.normalize {
+		moarray2.NormalizeGonumVectors(vectors)
+	}
+	return vectors, nil
+}
+
+// checkDims fixes the dimension on the first vector, and checks it for the next ones.
+func (km *OnlineClusterer) checkDims(dims int) error {
+	if km.dims == 0 {
+		km.dims = dims
+		return nil
+	}
+	if dims != km.dims {
+		return moerr.NewArrayInvalidOpNoCtx(km.dims, dims)
+	}
+	return nil
+}
+
+// update moves the closest centroid of v towards it. Until there are k centroids, a vector that does not
+// lie on an existing centroid becomes a new one.
+func (km *OnlineClusterer) update(v *mat.VecDense) {
+	closest, minDist := -1, math.MaxFloat64
+	for c := range km.centroids {
+		if dist := km.distFn(v, km.centroids[c]); dist < minDist {
+			closest, minDist = c, dist
+		}
+	}
+
+	if len(km.centroids) < km.clusterCnt && (closest == -1 || minDist > 0) {
+		km.centroids = append(km.centroids, mat.VecDenseCopyOf(v))
+		km.clusterSizes = append(km.clusterSizes, 1)
+		return
+	}
+
+	km.clusterSizes[closest]++
+	eta := 1 / float64(km.clusterSizes[closest])
+	km.centroids[closest].ScaleVec(1-eta, km.centroids[closest])
+	km.centroids[closest].AddScaledVec(km.centroids[closest], eta, v)
+}
@@ -0,0 +1,195 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"context"
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestOnlineClusterer_PartialFit(t *testing.T) {
+	tests := []struct {
+		name      string
+		batches   [][][]float64
+		opts      []Option
+		want      [][]float64
+		wantSizes []int64
+	}{
+		{
+			name:      "Test 1 - running means",
+			batches:   [][][]float64{{{1}, {10}, {3}, {12}}},
+			want:      [][]float64{{2}, {11}},
+			wantSizes: []int64{2, 2},
+		},
+		{
+			name:      "Test 2 - several batches",
+			batches:   [][][]float64{{{1}}, {{10}, {3}}, {}, {{12}}},
+			want:      [][]float64{{2}, {11}},
+			wantSizes: []int64{2, 2},
+		},
+		{
+			name:      "Test 3 - duplicates do not become centroids",
+			batches:   [][][]float64{{{1}, {1}, {5}}},
+			want:      [][]float64{{1}, {5}},
+			wantSizes: []int64{2, 1},
+		},
+		{
+			name:      "Test 4 - initial centroids count as one vector",
+			batches:   [][][]float64{{{2}, {12}, {12}}},
+			opts:      []Option{WithInitialCentroids([][]float64{{0}, {9}})},
+			want:      [][]float64{{1}, {11}},
+			wantSizes: []int64{2, 3},
+		},
+		{
+			name:      "Test 5 - normalize",
+			batches:   [][][]float64{{{2, 0}, {0, 3}, {4, 0}}},
+			opts:      []Option{WithNormalize(true)},
+			want:      [][]float64{{1, 0}, {0, 1}},
+			wantSizes: []int64{2, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, err := NewOnlineClusterer(2, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewOnlineClusterer() error = %v", err)
+			}
+			for _, batch := range tt.batches {
+				if err = km.PartialFit(batch); err != nil {
+					t.Fatalf("PartialFit() error = %v", err)
+				}
+			}
+			got, err := km.Centroids()
+			if err != nil {
+				t.Fatalf("Centroids() error = %v", err)
+			}
+			if !assertx.InEpsilonF64Slices(tt.want, got) {
+				t.Errorf("Centroids() got = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.wantSizes, km.ClusterSizes()) {
+				t.Errorf("ClusterSizes() got = %v, want %v", km.ClusterSizes(), tt.wantSizes)
+			}
+		})
+	}
+}
+
+func TestOnlineClusterer_Errors(t *testing.T) {
+	if _, err := NewOnlineClusterer(0); err == nil {
+		t.Errorf("NewOnlineClusterer() with 0 clusters, want error")
+	}
+	if _, err := NewOnlineClusterer(2, WithInitialCentroids([][]float64{{1}})); err == nil {
+		t.Errorf("NewOnlineClusterer() with 1 initial centroid, want error")
+	}
+
+	km, err := NewOnlineClusterer(2)
+	if err != nil {
+		t.Fatalf("NewOnlineClusterer() error = %v", err)
+	}
+	if err = km.PartialFit([][]float64{{1, 2}, {1, 2}}); err != nil {
+		t.Fatalf("PartialFit() error = %v", err)
+	}
+	if _, err = km.Centroids(); err == nil {
+		t.Errorf("Centroids() with 1 distinct vector, want error")
+	}
+	if _, err = km.Assigner(); err == nil {
+		t.Errorf("Assigner() with 1 distinct vector, want error")
+	}
+
+	// the whole batch is rejected, so the first vector must not become a centroid.
+	if err = km.PartialFit([][]float64{{3, 4}, {3, 4, 5}}); err == nil {
+		t.Errorf("PartialFit() with different dimensions, want error")
+	}
+	if err = km.PartialFit([][]float64{{3, 4, 5}}); err == nil {
+		t.Errorf("PartialFit() with a new dimension, want error")
+	}
+	if want := []int64{2}; !reflect.DeepEqual(want, km.ClusterSizes()) {
+		t.Errorf("ClusterSizes() got = %v, want %v", km.ClusterSizes(), want)
+	}
+}
+
+func TestOnlineClusterer_FitChannel(t *testing.T) {
+	data := blobs(3000, 5, 4, 1)
+
+	vectors := make(chan []float64)
+	go func() {
+		defer close(vectors)
+		for _, v := range data {
+			vectors <- v
+		}
+	}()
+
+	km, err := NewOnlineClusterer(5)
+	if err != nil {
+		t.Fatalf("NewOnlineClusterer() error = %v", err)
+	}
+	if err = km.FitChannel(context.Background(), vectors); err != nil {
+		t.Fatalf("FitChannel() error = %v", err)
+	}
+
+	// the first 5 vectors come from different blobs, so each centroid is the mean of a whole blob.
+	got, err := km.Centroids()
+	if err != nil {
+		t.Fatalf("Centroids() error = %v", err)
+	}
+	for c := range got {
+		for d := range got[c] {
+			if want := float64(c * 100); math.Abs(got[c][d]-want) > 0.2 {
+				t.Errorf("Centroids()[%d] got = %v, want close to %v", c, got[c], want)
+			}
+		}
+	}
+
+	// the same vectors in a single batch give the same centroids.
+	batch, err := NewOnlineClusterer(5)
+	if err != nil {
+		t.Fatalf("NewOnlineClusterer() error = %v", err)
+	}
+	if err = batch.PartialFit(data); err != nil {
+		t.Fatalf("PartialFit() error = %v", err)
+	}
+	want, _ := batch.Centroids()
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("FitChannel() centroids differ from PartialFit()")
+	}
+
+	assigner, err := km.Assigner()
+	if err != nil {
+		t.Fatalf("Assigner() error = %v", err)
+	}
+	labels, err := assigner.Assign(data[:10])
+	if err != nil {
+		t.Fatalf("Assign() error = %v", err)
+	}
+	for i, label := range labels {
+		if label != i%5 {
+			t.Errorf("Assign() got = %v, want vector %d in cluster %d", labels, i, i%5)
+		}
+	}
+}
+
+func TestOnlineClusterer_FitChannelCancelled(t *testing.T) {
+	km, err := NewOnlineClusterer(2)
+	if err != nil {
+		t.Fatalf("NewOnlineClusterer() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err = km.FitChannel(ctx, make(chan []float64)); err != context.Canceled {
+		t.Errorf("FitChannel() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	"math/rand"
+)
+
+// Option configures the clusterers built by NewClusterer and the New*Clusterer functions.
+// The options are validated together by validateArgs when the clusterer is built.
+type Option func(*options)
+
+type options struct {
+	algorithm          kmeans.Algorithm
+	maxIterations      int
+	deltaThreshold     float64
+	convergenceType    kmeans.ConvergenceType
+	distanceType       kmeans.DistanceType
+	initType           kmeans.InitType
+	initRounds         int
+	initOversampling   float64
+	initialCentroids   [][]float64
+	initializerFactory InitializerFactory
+	normalize          bool
+	seed               int64
+	randSource         rand.Source
+	workers            int
+	emptyClusterPolicy kmeans.EmptyClusterPolicy
+	restarts           int
+	concurrentRestarts bool
+	yinyangGroups      int
+	batchSize          int
+	maxNoImprovement   int
+	autoKCriterion     kmeans.AutoKCriterion
+	sweepSampleSize    int
+	stats              bool
+}
+
+func defaultOptions() options {
+	return options{
+		algorithm:          kmeans.Elkan,
+		maxIterations:      kmeans.DefaultMaxIterations,
+		deltaThreshold:     kmeans.DefaultDeltaThreshold,
+		convergenceType:    kmeans.ReassignmentRatio,
+		distanceType:       kmeans.L2Distance,
+		initType:           kmeans.KmeansPlusPlus,
+		initRounds:         DefaultKMeansParallelRounds,
+		initOversampling:   DefaultKMeansParallelOversampling,
+		normalize:          false,
+		seed:               kmeans.DefaultRandSeed,
+		workers:            0,
+		emptyClusterPolicy: kmeans.EmptyClusterRandomVector,
+		restarts:           1,
+		concurrentRestarts: false,
+		yinyangGroups:      0,
+		batchSize:          DefaultMiniBatchSize,
+		maxNoImprovement:   DefaultMiniBatchMaxNoImprovement,
+		autoKCriterion:     kmeans.XMeans,
+	}
+}
+
+func newOptions(opts []Option) options {
+	cfg := defaultOptions()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithAlgorithm sets the algorithm of the clusterer built by NewClusterer. Default is kmeans.Elkan.
+func WithAlgorithm(algorithm kmeans.Algorithm) Option {
+	return func(o *options) {
+		o.algorithm = algorithm
+	}
+}
+
+// WithMaxIter sets the maximum number of iterations. With 0, the vectors are only assigned to the initial
+// centroids. Default is kmeans.DefaultMaxIterations.
+func WithMaxIter(maxIterations int) Option {
+	return func(o *options) {
+		o.maxIterations = maxIterations
+	}
+}
+
+// WithTolerance sets the deltaThreshold used for early convergence. It must be in (0, 1), except for
+// kmeans.CentroidShift where it is a distance and only needs to be positive.
+// Default is kmeans.DefaultDeltaThreshold.
+func WithTolerance(deltaThreshold float64) Option {
+	return func(o *options) {
+		o.deltaThreshold = deltaThreshold
+	}
+}
+
+// WithConvergence sets the criterion compared against the tolerance. Default is kmeans.ReassignmentRatio.
+func WithConvergence(convergenceType kmeans.ConvergenceType) Option {
+	return func(o *options) {
+		o.convergenceType = convergenceType
+	}
+}
+
+// WithDistance sets the distance type. Default is kmeans.L2Distance.
+func WithDistance(distanceType kmeans.DistanceType) Option {
+	return func(o *options) {
+		o.distanceType = distanceType
+	}
+}
+
+// WithInit sets the centroid initialization algorithm. Default is kmeans.KmeansPlusPlus.
+func WithInit(initType kmeans.InitType) Option {
+	return func(o *options) {
+		o.initType = initType
+	}
+}
+
+// WithInitializer sets a custom centroid initialization algorithm. It takes precedence over WithInit.
+// The same initializer is used by all the runs, so it must be safe for concurrent use with
+// WithConcurrentRestarts.
+func WithInitializer(initializer Initializer) Option {
+	return WithInitializerFactory(func(InitializerConfig) Initializer {
+		return initializer
+	})
+}
+
+// WithInitializerFactory sets a custom centroid initialization algorithm, built for each run. It takes
+// precedence over WithInit. The factory is given the distance function and the random source of the run.
+func WithInitializerFactory(factory InitializerFactory) Option {
+	return func(o *options) {
+		o.initializerFactory = factory
+	}
+}
+
+// WithKMeansParallelConfig sets the number of sampling rounds and the oversampling factor used by the
+// kmeans.KmeansParallel initialization. Defaults are DefaultKMeansParallelRounds and
+// DefaultKMeansParallelOversampling.
+func WithKMeansParallelConfig(rounds int, oversamplingFactor float64) Option {
+	return func(o *options) {
+		o.initRounds = rounds
+		o.initOversampling = oversamplingFactor
+	}
+}
+
+// WithInitialCentroids starts the clustering from the given centroids instead of running the initializer,
+// e.g. to warm-start from the centroids of a previous index. There must be clusterCnt centroids with the
+// same dimension as the vectors. If the vectors are normalized, the centroids are normalized too.
+func WithInitialCentroids(centroids [][]float64) Option {
+	return func(o *options) {
+		o.initialCentroids = centroids
+	}
+}
+
+// WithNormalize enables normalizing the input vectors, which is required for spherical kmeans.
+func WithNormalize(normalize bool) Option {
+	return func(o *options) {
+		o.normalize = normalize
+	}
+}
+
+// WithSeed sets the seed of the random numbers used by the initializer and the clusterer.
+// Runs with the same seed and the same input return bit-identical results, whatever the number of workers.
+// Default is kmeans.DefaultRandSeed.
+func WithSeed(seed int64) Option {
+	return func(o *options) {
+		o.seed = seed
+	}
+}
+
+// WithRandSource sets the source of the random numbers used by the initializer and the clusterer.
+// It takes precedence over WithSeed. Unlike a seed, the source is not reset between two Cluster calls,
+// so every call continues the sequence of src.
+func WithRandSource(src rand.Source) Option {
+	return func(o *options) {
+		o.randSource = src
+	}
+}
+
+// WithWorkers sets the number of goroutines used for the distance computations.
+// The results do not depend on the number of workers.
+// Default is 0, which means GOMAXPROCS.
+func WithWorkers(workers int) Option {
+	return func(o *options) {
+		o.workers = workers
+	}
+}
+
+// WithEmptyClusterPolicy sets how a centroid is re-seeded when its cluster becomes empty.
+// Default is kmeans.EmptyClusterRandomVector.
+func WithEmptyClusterPolicy(policy kmeans.EmptyClusterPolicy) Option {
+	return func(o *options) {
+		o.emptyClusterPolicy = policy
+	}
+}
+
+// WithRestarts runs the clustering n times with different seeds and keeps the run with the lowest SSE.
+// It reduces the impact of a bad initialization, at the cost of n times the clustering time.
+// The seeds of the runs are drawn from the random source of the clusterer. Default is 1.
+func WithRestarts(n int) Option {
+	return func(o *options) {
+		o.restarts = n
+	}
+}
+
+// WithConcurrentRestarts runs the restarts concurrently. It needs memory for all the runs at once,
+// whereas sequential restarts only keep the current and the best run. Default is false.
+func WithConcurrentRestarts(concurrent bool) Option {
+	return func(o *options) {
+		o.concurrentRestarts = concurrent
+	}
+}
+
+// WithYinyangGroups sets the number of centroid groups of kmeans.Yinyang. Each vector keeps one lower bound per
+// group, so more groups skip more distance computations, at the cost of n*groups memory and bound updates.
+// Default is 0, which means clusterCnt/10 groups.
+func WithYinyangGroups(groups int) Option {
+	return func(o *options) {
+		o.yinyangGroups = groups
+	}
+}
+
+// WithBatchSize sets the number of vectors sampled per iteration by kmeans.MiniBatch.
+// Default is DefaultMiniBatchSize.
+func WithBatchSize(size int) Option {
+	return func(o *options) {
+		o.batchSize = size
+	}
+}
+
+// WithMaxNoImprovement stops kmeans.MiniBatch when the smoothed inertia has not improved for the given number
+// of consecutive mini-batches. 0 disables the early stopping. Default is DefaultMiniBatchMaxNoImprovement.
+func WithMaxNoImprovement(batches int) Option {
+	return func(o *options) {
+		o.maxNoImprovement = batches
+	}
+}
+
+// WithAutoKCriterion sets the criterion used by AutoKClusterer to split the clusters. Default is kmeans.XMeans.
+func WithAutoKCriterion(criterion kmeans.AutoKCriterion) Option {
+	return func(o *options) {
+		o.autoKCriterion = criterion
+	}
+}
+
+// WithSweepSampleSize makes SweepK cluster and score a random sample of size vectors, drawn once and shared by
+// all the values of k. Default is 0, which uses all the vectors.
+func WithSweepSampleSize(size int) Option {
+	return func(o *options) {
+		o.sweepSampleSize = size
+	}
+}
+
+// WithStats collects the statistics of the runs, reported by Stats. It wraps the distance function with an
+// atomic counter, and computes the SSE after every iteration, which slows the clustering down.
+// Default is false.
+func WithStats(collect bool) Option {
+	return func(o *options) {
+		o.stats = collect
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"context"
+	"github.com/arjunsk/kmeans"
+	"sync"
+)
+
+// clusterRestarts runs km.restarts independent clusterings with different seeds and keeps the one with the
+// lowest SSE. The runs share the (read-only) input vectors, but each run has its own state and centroids.
+// On cancellation, the best run so far is kept and the error is returned.
+func (km *clusterer) clusterRestarts(ctx context.Context) error {
+	if km.randSource == nil {
+		km.rand.Seed(km.seed)
+	}
+	seeds := make([]int64, km.restarts)
+	for i := range seeds {
+		seeds[i] = km.rand.Int63()
+	}
+
+	var best *clusterer
+	var bestSSE float64
+	var bestErr error
+	keepBest := func(run *clusterer, err error) {
+		if run.centroids == nil {
+			// cancelled before the centroids were initialized.
+			if bestErr == nil {
+				bestErr = err
+			}
+			return
+		}
+		if sse := run.SSE(); best == nil || sse < bestSSE {
+			best, bestSSE = run, sse
+		}
+		if err != nil && bestErr == nil {
+			bestErr = err
+		}
+	}
+
+	if km.concurrentRestarts {
+		runs := make([]*clusterer, km.restarts)
+		errs := make([]error, km.restarts)
+		var wg sync.WaitGroup
+		for i := range runs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				runs[i] = km.algo.newRun(seeds[i]).base()
+				errs[i] = runs[i].clusterOnce(ctx)
+			}(i)
+		}
+		wg.Wait()
+
+		// pick the best in the order of the runs, so that ties are resolved deterministically.
+		for i := range runs {
+			keepBest(runs[i], errs[i])
+		}
+	} else {
+		for i := range seeds {
+			run := km.algo.newRun(seeds[i]).base()
+			err := run.clusterOnce(ctx)
+			keepBest(run, err)
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	if best == nil {
+		km.convergenceReason = kmeans.Cancelled
+		return bestErr
+	}
+
+	km.centroids = best.centroids
+	km.assignments = best.assignments
+	km.iterations = best.iterations
+	km.convergenceReason = best.convergenceReason
+	km.emptyClusterReseeds = best.emptyClusterReseeds
+	km.stats = best.stats
+	if bestErr != nil {
+		km.convergenceReason = kmeans.Cancelled
+	}
+	return bestErr
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"context"
+	"errors"
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestElkanClusterer_Restarts(t *testing.T) {
+	data := make([][]float64, 300)
+	populateRandData(300, 4, data)
+	k := 8
+	restarts := 4
+
+	// the restarts draw their seeds from the clusterer's random source.
+	seedRand := rand.New(rand.NewSource(kmeans.DefaultRandSeed))
+	wantSSE := math.MaxFloat64
+	for i := 0; i < restarts; i++ {
+		ekm, err := NewElkanClusterer(data, k, WithInit(kmeans.Random), WithSeed(seedRand.Int63()))
+		if err != nil {
+			t.Fatalf("Error while creating KMeans object %v", err)
+		}
+		if _, err = ekm.Cluster(); err != nil {
+			t.Fatalf("Cluster() error = %v", err)
+		}
+		wantSSE = math.Min(wantSSE, ekm.SSE())
+	}
+
+	var results [][][]float64
+	for _, concurrent := range []bool{false, true} {
+		ekm, err := NewElkanClusterer(data, k, WithInit(kmeans.Random),
+			WithRestarts(restarts), WithConcurrentRestarts(concurrent))
+		if err != nil {
+			t.Fatalf("Error while creating KMeans object %v", err)
+		}
+		got, err := ekm.Cluster()
+		if err != nil {
+			t.Fatalf("Cluster() error = %v", err)
+		}
+		if !assertx.InEpsilonF64(wantSSE, ekm.SSE()) {
+			t.Errorf("concurrent=%v: SSE() got = %v, want %v", concurrent, ekm.SSE(), wantSSE)
+		}
+		if res := ekm.Result(); res.ConvergenceReason == kmeans.NotConverged || res.ConvergenceReason == kmeans.Cancelled {
+			t.Errorf("concurrent=%v: ConvergenceReason got = %v", concurrent, res.ConvergenceReason)
+		}
+		results = append(results, got)
+	}
+	if !reflect.DeepEqual(results[0], results[1]) {
+		t.Errorf("sequential and concurrent restarts returned different centroids")
+	}
+}
+
+func TestElkanClusterer_Restarts_Cancelled(t *testing.T) {
+	data := make([][]float64, 100)
+	populateRandData(100, 4, data)
+
+	for _, concurrent := range []bool{false, true} {
+		ekm, err := NewElkanClusterer(data, 4, WithRestarts(3), WithConcurrentRestarts(concurrent))
+		if err != nil {
+			t.Fatalf("Error while creating KMeans object %v", err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		got, err := ekm.ClusterContext(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("concurrent=%v: ClusterContext() error = %v, want %v", concurrent, err, context.Canceled)
+		}
+		if got != nil {
+			t.Errorf("concurrent=%v: ClusterContext() got = %v, want nil", concurrent, got)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	"gonum.org/v1/gonum/mat"
+	"sync/atomic"
+	"time"
+)
+
+// Stats returns the statistics of the last run, or nil if they are not collected (see WithStats).
+// With restarts, they are the statistics of the kept run.
+func (km *clusterer) Stats() *kmeans.RunStats {
+	return km.stats
+}
+
+// startStats resets the statistics, and wraps the distance function with a call counter for the run.
+func (km *clusterer) startStats() {
+	km.phases = kmeans.PhaseDurations{}
+	if !km.collectStats {
+		return
+	}
+
+	km.stats = &kmeans.RunStats{}
+	if km.statsDistFn == nil {
+		km.statsDistFn = km.distFn
+	}
+	distFn, counter := km.statsDistFn, &km.stats.DistanceComputations
+	km.distFn = func(v1, v2 *mat.VecDense) float64 {
+		atomic.AddInt64(counter, 1)
+		return distFn(v1, v2)
+	}
+}
+
+// finishStats completes the statistics of the run, and restores the distance function.
+func (km *clusterer) finishStats() {
+	if km.stats == nil {
+		return
+	}
+	km.distFn = km.statsDistFn
+	km.stats.Iterations = km.iterations
+	km.stats.PhaseDurations = km.phases
+}
+
+// startIteration marks the distance computations done before the iteration, and returns its start time.
+func (km *clusterer) startIteration() time.Time {
+	if km.stats != nil {
+		km.statsDistMark = atomic.LoadInt64(&km.stats.DistanceComputations)
+	}
+	return time.Now()
+}
+
+// recordIteration adds the statistics of an iteration that started at start. It must be called once the
+// centroids are updated, and computes the SSE without counting its distance computations or time.
+func (km *clusterer) recordIteration(changes int, maxShift float64, start time.Time) {
+	if km.stats == nil {
+		return
+	}
+	elapsed := time.Since(start)
+
+	sse := km.sse(km.statsDistFn)
+	distCount := atomic.LoadInt64(&km.stats.DistanceComputations)
+	km.stats.PerIteration = append(km.stats.PerIteration, kmeans.IterationStats{
+		Changes:              changes,
+		SSE:                  sse,
+		MaxShift:             maxShift,
+		DistanceComputations: distCount - km.statsDistMark,
+		Duration:             elapsed,
+	})
+}
+
+// uncountedDistFn returns the distance function without the call counter of the statistics.
+func (km *clusterer) uncountedDistFn() kmeans.DistanceFunction {
+	if km.statsDistFn != nil {
+		return km.statsDistFn
+	}
+	return km.distFn
+}
+
+// lap returns the time elapsed since *start, and resets *start to now.
+func lap(start *time.Time) time.Duration {
+	now := time.Now()
+	elapsed := now.Sub(*start)
+	*start = now
+	return elapsed
+}
@@ -0,0 +1,188 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"reflect"
+	"testing"
+)
+
+func TestElkanClusterer_Stats(t *testing.T) {
+	n, k := 500, 10
+	data := make([][]float64, n)
+	populateRandData(n, 4, data)
+
+	ekm, err := NewElkanClusterer(data, k, WithInit(kmeans.Random), WithStats(true))
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	if _, err = ekm.Cluster(); err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+
+	stats := ekm.Stats()
+	if stats == nil {
+		t.Fatalf("Stats() got = nil")
+	}
+	iters := int64(stats.Iterations)
+	if stats.Iterations != ekm.Result().Iterations || len(stats.PerIteration) != stats.Iterations {
+		t.Errorf("Iterations got = %v with %v iteration stats, want %v",
+			stats.Iterations, len(stats.PerIteration), ekm.Result().Iterations)
+	}
+
+	// random init computes no distance. Then the bounds are initialized with n*k distances, and each
+	// iteration computes the k*(k-1)/2 centroid distances, the k centroid shifts, and the n*k vector-centroid
+	// distances that were not skipped.
+	nk := int64(n * k)
+	want := nk + iters*int64(k*(k-1)/2+k) + iters*nk - stats.SkippedByStep2 - stats.SkippedByStep3
+	if stats.DistanceComputations != want {
+		t.Errorf("DistanceComputations got = %v, want %v", stats.DistanceComputations, want)
+	}
+	if stats.SkippedByStep2 == 0 || stats.SkippedByStep3 == 0 {
+		t.Errorf("Skipped got = %v and %v, want > 0", stats.SkippedByStep2, stats.SkippedByStep3)
+	}
+
+	var perIteration int64
+	for _, it := range stats.PerIteration {
+		perIteration += it.DistanceComputations
+	}
+	if stats.DistanceComputations-perIteration != nk {
+		t.Errorf("DistanceComputations outside the iterations got = %v, want %v",
+			stats.DistanceComputations-perIteration, nk)
+	}
+
+	last := stats.PerIteration[len(stats.PerIteration)-1]
+	if !assertx.InEpsilonF64(ekm.SSE(), last.SSE) {
+		t.Errorf("SSE of the last iteration got = %v, want %v", last.SSE, ekm.SSE())
+	}
+	for i := 1; i < len(stats.PerIteration); i++ {
+		if stats.PerIteration[i].SSE > stats.PerIteration[i-1].SSE*(1+1e-9) {
+			t.Errorf("SSE increased at iteration %v: %v > %v", i, stats.PerIteration[i].SSE, stats.PerIteration[i-1].SSE)
+		}
+	}
+	if stats.PhaseDurations.InitBounds <= 0 || stats.PhaseDurations.Assignment <= 0 {
+		t.Errorf("PhaseDurations got = %+v", stats.PhaseDurations)
+	}
+
+	// the SSE and the statistics are computed without counting the distances.
+	_ = ekm.SSE()
+	if got := ekm.Stats().DistanceComputations; got != stats.DistanceComputations {
+		t.Errorf("DistanceComputations after SSE() got = %v, want %v", got, stats.DistanceComputations)
+	}
+}
+
+func TestElkanClusterer_Stats_SSEImprovement(t *testing.T) {
+	n, k := 500, 10
+	data := make([][]float64, n)
+	populateRandData(n, 4, data)
+
+	ekm, err := NewElkanClusterer(data, k, WithInit(kmeans.Random), WithStats(true),
+		WithConvergence(kmeans.SSEImprovement), WithTolerance(1e-6))
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	if _, err = ekm.Cluster(); err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+
+	// the SSE of the convergence check is not counted, so only the bounds initialization is outside the
+	// iterations.
+	stats := ekm.Stats()
+	var perIteration int64
+	for _, it := range stats.PerIteration {
+		perIteration += it.DistanceComputations
+	}
+	if got := stats.DistanceComputations - perIteration; got != int64(n*k) {
+		t.Errorf("DistanceComputations outside the iterations got = %v, want %v", got, n*k)
+	}
+}
+
+func TestClusterer_Stats(t *testing.T) {
+	data := make([][]float64, 300)
+	populateRandData(300, 4, data)
+	algorithms := []kmeans.Algorithm{kmeans.Elkan, kmeans.Hamerly, kmeans.Yinyang, kmeans.Lloyd}
+
+	for _, algorithm := range algorithms {
+		var results []*kmeans.RunStats
+		for _, workers := range []int{1, 4} {
+			km, err := NewClusterer(data, 6, WithAlgorithm(algorithm), WithWorkers(workers), WithStats(true))
+			if err != nil {
+				t.Fatalf("%v: NewClusterer() error = %v", algorithm, err)
+			}
+			if _, err = km.Cluster(); err != nil {
+				t.Fatalf("%v: Cluster() error = %v", algorithm, err)
+			}
+			stats := km.(interface{ Stats() *kmeans.RunStats }).Stats()
+			if stats == nil || stats.DistanceComputations == 0 || len(stats.PerIteration) != stats.Iterations {
+				t.Fatalf("%v: Stats() got = %+v", algorithm, stats)
+			}
+			results = append(results, stats)
+		}
+
+		// the counts do not depend on the number of workers, unlike the durations.
+		for _, stats := range results {
+			stats.PhaseDurations = kmeans.PhaseDurations{}
+			for i := range stats.PerIteration {
+				stats.PerIteration[i].Duration = 0
+			}
+		}
+		if !reflect.DeepEqual(results[0], results[1]) {
+			t.Errorf("%v: Stats() got different statistics with 1 and 4 workers", algorithm)
+		}
+	}
+}
+
+func TestElkanClusterer_Stats_Disabled(t *testing.T) {
+	data := make([][]float64, 100)
+	populateRandData(100, 4, data)
+
+	ekm, err := NewElkanClusterer(data, 4)
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	if _, err = ekm.Cluster(); err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+	if ekm.Stats() != nil {
+		t.Errorf("Stats() got = %+v, want nil", ekm.Stats())
+	}
+}
+
+func TestElkanClusterer_Stats_Restarts(t *testing.T) {
+	data := make([][]float64, 300)
+	populateRandData(300, 4, data)
+
+	for _, concurrent := range []bool{false, true} {
+		ekm, err := NewElkanClusterer(data, 8, WithRestarts(3), WithConcurrentRestarts(concurrent), WithStats(true))
+		if err != nil {
+			t.Fatalf("Error while creating KMeans object %v", err)
+		}
+		if _, err = ekm.Cluster(); err != nil {
+			t.Fatalf("Cluster() error = %v", err)
+		}
+
+		// the statistics are the ones of the kept run.
+		stats := ekm.Stats()
+		if stats == nil || stats.Iterations != ekm.Result().Iterations || len(stats.PerIteration) != stats.Iterations {
+			t.Fatalf("concurrent=%v: Stats() got = %+v", concurrent, stats)
+		}
+		last := stats.PerIteration[len(stats.PerIteration)-1]
+		if !assertx.InEpsilonF64(ekm.SSE(), last.SSE) {
+			t.Errorf("concurrent=%v: SSE of the last iteration got = %v, want %v", concurrent, last.SSE, ekm.SSE())
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"context"
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/metrics"
+	moarray2 "github.com/arjunsk/kmeans/utils/moarray"
+	"github.com/arjunsk/kmeans/utils/moerr"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// SweepResult holds the scores of the clustering with K clusters. The metrics that need at least 2 non-empty
+// clusters are NaN otherwise.
+type SweepResult struct {
+	K int
+	// SSE is the sum of squared errors, lower is better but it always decreases with K.
+	SSE float64
+	// Silhouette is in [-1, 1], higher is better.
+	Silhouette float64
+	// DaviesBouldin is >= 0, lower is better.
+	DaviesBouldin float64
+	// CalinskiHarabasz is >= 0, higher is better.
+	CalinskiHarabasz float64
+	// Iterations is the number of iterations of the run.
+	Iterations int
+}
+
+// SweepReport is the outcome of SweepK.
+type SweepReport struct {
+	// Results holds the scores of each K, in increasing order of K.
+	Results []SweepResult
+	// ElbowK is the K at the elbow of the SSE curve: the point farthest below the line through the first and
+	// last points, once both axes are scaled to [0, 1]. With less than 3 values of K, it is the smallest one.
+	ElbowK int
+}
+
+// resultClusterer is implemented by the clusterers built by NewClusterer.
+type resultClusterer interface {
+	ClusterContext(ctx context.Context) ([][]float64, error)
+	Result() *kmeans.ClusterResult
+}
+
+// SweepK clusters the vectors with each of the given k, using NewClusterer and the options, and scores the
+// clusterings with metrics.Silhouette, metrics.DaviesBouldin and metrics.CalinskiHarabasz. The values of k
+// are run in parallel, and the WithWorkers goroutines (GOMAXPROCS by default) are split between the runs.
+// With WithRandSource, the seed of each run is drawn from the source in increasing order of k, so the runs
+// do not share it.
+// As the silhouette takes O(n^2) distance computations, WithSweepSampleSize should be set for large inputs.
+func SweepK(ctx context.Context, vectors [][]float64, ks []int, opts ...Option) (*SweepReport, error) {
+	cfg := newOptions(opts)
+	if len(ks) == 0 {
+		return nil, moerr.NewInternalErrorNoCtx("cluster counts is empty")
+	}
+	if cfg.sweepSampleSize < 0 {
+		return nil, moerr.NewInternalErrorNoCtx("sweep sample size is out of bounds (must be >= 0)")
+	}
+
+	sample := vectors
+	if cfg.sweepSampleSize > 0 && cfg.sweepSampleSize < len(vectors) {
+		// the sample keeps the input order, and does not depend on the random source of the runs.
+		picked := rand.New(rand.NewSource(cfg.seed)).Perm(len(vectors))[:cfg.sweepSampleSize]
+		sort.Ints(picked)
+		sample = make([][]float64, len(picked))
+		for i, x := range picked {
+			sample[i] = vectors[x]
+		}
+	}
+
+	sortedKs := append([]int{}, ks...)
+	sort.Ints(sortedKs)
+	distinct := 0
+	for _, k := range sortedKs {
+		if distinct == 0 || k != sortedKs[distinct-1] {
+			sortedKs[distinct] = k
+			distinct++
+		}
+	}
+	sortedKs = sortedKs[:distinct]
+
+	// the options are validated for every k before any run starts.
+	for _, k := range sortedKs {
+		if err := validateArgs(sample, k, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	gonumSample, err := moarray2.ToGonumVectors[float64](sample...)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.normalize {
+		moarray2.NormalizeGonumVectors(gonumSample)
+	}
+	distanceFunction, err := resolveDistanceFn(cfg.distanceType)
+	if err != nil {
+		return nil, err
+	}
+
+	runOpts := make([][]Option, len(sortedKs))
+	var rnd *rand.Rand
+	if cfg.randSource != nil {
+		rnd = rand.New(cfg.randSource)
+	}
+	for i := range sortedKs {
+		runOpts[i] = append([]Option{}, opts...)
+		if rnd != nil {
+			runOpts[i] = append(runOpts[i], WithRandSource(nil), WithSeed(rnd.Int63()))
+		}
+	}
+
+	// the runs do not use more than the workers altogether.
+	workers := cfg.workers
+	if workers == 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	concurrency := workers
+	if concurrency > len(sortedKs) {
+		concurrency = len(sortedKs)
+	}
+	for i := range runOpts {
+		runOpts[i] = append(runOpts[i], WithWorkers(workers/concurrency))
+	}
+
+	results := make([]SweepResult, len(sortedKs))
+	errs := make([]error, len(sortedKs))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, k := range sortedKs {
+		wg.Add(1)
+		go func(i, k int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			results[i], errs[i] = sweepRun(ctx, sample, gonumSample, k, distanceFunction, runOpts[i])
+		}(i, k)
+	}
+	wg.Wait()
+	for _, err = range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &SweepReport{
+		Results: results,
+		ElbowK:  elbow(results),
+	}, nil
+}
+
+// sweepRun clusters the sample into k clusters, and scores the clustering on gonumSample.
+func sweepRun(ctx context.Context, sample [][]float64, gonumSample []*mat.VecDense, k int,
+	distFn kmeans.DistanceFunction, opts []Option) (SweepResult, error) {
+	km, err := NewClusterer(sample, k, opts...)
+	if err != nil {
+		return SweepResult{}, err
+	}
+	run := km.(resultClusterer)
+	if _, err = run.ClusterContext(ctx); err != nil {
+		return SweepResult{}, err
+	}
+	result := run.Result()
+
+	scores := SweepResult{
+		K:                k,
+		SSE:              result.SSE,
+		Silhouette:       math.NaN(),
+		DaviesBouldin:    math.NaN(),
+		CalinskiHarabasz: math.NaN(),
+		Iterations:       result.Iterations,
+	}
+	nonEmpty := 0
+	for _, size := range result.ClusterSizes {
+		if size > 0 {
+			nonEmpty++
+		}
+	}
+	if nonEmpty < 2 {
+		return scores, nil
+	}
+
+	centroids, err := moarray2.ToGonumVectors[float64](result.Centroids...)
+	if err != nil {
+		return SweepResult{}, err
+	}
+	if scores.Silhouette, err = metrics.Silhouette(gonumSample, result.Labels, centroids, distFn); err != nil {
+		return SweepResult{}, err
+	}
+	if scores.DaviesBouldin, err = metrics.DaviesBouldin(gonumSample, result.Labels, centroids, distFn); err != nil {
+		return SweepResult{}, err
+	}
+	if nonEmpty < len(gonumSample) {
+		scores.CalinskiHarabasz, err = metrics.CalinskiHarabasz(gonumSample, result.Labels, centroids, distFn)
+		if err != nil {
+			return SweepResult{}, err
+		}
+	}
+	return scores, nil
+}
+
+// elbow returns the K farthest below the line through the first and last points of the (K, SSE) curve, with
+// both axes scaled to [0, 1]. Ties go to the smallest K.
+func elbow(results []SweepResult) int {
+	if len(results) < 3 {
+		return results[0].K
+	}
+
+	first, last := results[0], results[len(results)-1]
+	kRange := float64(last.K - first.K)
+	sseRange := first.SSE - last.SSE
+	if sseRange <= 0 {
+		return first.K
+	}
+
+	// with scaled axes the line goes from (0, 1) to (1, 0), so the distance of a point below it is
+	// proportional to 1 - x - y.
+	best, bestDist := first.K, 0.0
+	for _, r := range results[1 : len(results)-1] {
+		x := float64(r.K-first.K) / kRange
+		y := (r.SSE - last.SSE) / sseRange
+		if dist := 1 - x - y; dist > bestDist {
+			best, bestDist = r.K, dist
+		}
+	}
+	return best
+}
@@ -0,0 +1,169 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestSweepK(t *testing.T) {
+	// 4 blobs at the corners of a square.
+	random := rand.New(rand.NewSource(1))
+	data := make([][]float64, 400)
+	for i := range data {
+		data[i] = []float64{float64(i%2*100) + random.NormFloat64(), float64(i/2%2*100) + random.NormFloat64()}
+	}
+	report, err := SweepK(context.Background(), data, []int{5, 1, 8, 2, 3, 4, 7, 6, 4})
+	if err != nil {
+		t.Fatalf("SweepK() error = %v", err)
+	}
+	if len(report.Results) != 8 {
+		t.Fatalf("SweepK() got %d results, want 8", len(report.Results))
+	}
+
+	bestSilhouette, bestDaviesBouldin, bestCalinskiHarabasz := 1, 1, 1
+	for i, r := range report.Results {
+		if r.K != i+1 {
+			t.Fatalf("SweepK() Results[%d].K got = %v, want %v", i, r.K, i+1)
+		}
+		if r.K == 1 {
+			if !math.IsNaN(r.Silhouette) || !math.IsNaN(r.DaviesBouldin) || !math.IsNaN(r.CalinskiHarabasz) {
+				t.Errorf("SweepK() metrics with a single cluster got = %+v, want NaN", r)
+			}
+			continue
+		}
+		if r.Silhouette > report.Results[bestSilhouette].Silhouette {
+			bestSilhouette = i
+		}
+		if r.DaviesBouldin < report.Results[bestDaviesBouldin].DaviesBouldin {
+			bestDaviesBouldin = i
+		}
+		if r.CalinskiHarabasz > report.Results[bestCalinskiHarabasz].CalinskiHarabasz {
+			bestCalinskiHarabasz = i
+		}
+	}
+
+	// the 4 blobs are found by the elbow and by every metric.
+	if report.ElbowK != 4 {
+		t.Errorf("SweepK() ElbowK got = %v, want 4", report.ElbowK)
+	}
+	for name, best := range map[string]int{
+		"Silhouette":       bestSilhouette,
+		"DaviesBouldin":    bestDaviesBouldin,
+		"CalinskiHarabasz": bestCalinskiHarabasz,
+	} {
+		if k := report.Results[best].K; k != 4 {
+			t.Errorf("SweepK() best %s got K = %v, want 4", name, k)
+		}
+	}
+}
+
+func TestSweepK_Sample(t *testing.T) {
+	data := blobs(1000, 3, 4, 2)
+
+	var want *SweepReport
+	for _, workers := range []int{1, 3} {
+		got, err := SweepK(context.Background(), data, []int{2, 3, 4}, WithSweepSampleSize(200), WithWorkers(workers))
+		if err != nil {
+			t.Fatalf("SweepK() error = %v", err)
+		}
+		if want == nil {
+			want = got
+			continue
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("SweepK() with %d workers got = %+v, want %+v", workers, got, want)
+		}
+	}
+	if want.ElbowK != 3 {
+		t.Errorf("SweepK() ElbowK got = %v, want 3", want.ElbowK)
+	}
+
+	// a sample smaller than a k fails like NewClusterer.
+	if _, err := SweepK(context.Background(), data, []int{2, 20}, WithSweepSampleSize(10)); err == nil {
+		t.Errorf("SweepK() with k larger than the sample, want error")
+	}
+	if _, err := SweepK(context.Background(), data, nil); err == nil {
+		t.Errorf("SweepK() without k, want error")
+	}
+	if _, err := SweepK(context.Background(), data, []int{2, 3}, WithSweepSampleSize(-1)); err == nil {
+		t.Errorf("SweepK() with a negative sample size, want error")
+	}
+	if _, err := SweepK(context.Background(), data, []int{2, 3}, WithWorkers(-1)); err == nil {
+		t.Errorf("SweepK() with negative workers, want error")
+	}
+}
+
+func TestSweepK_RandSource(t *testing.T) {
+	data := blobs(400, 3, 2, 1)
+
+	// the runs draw their seeds from the source upfront, so they do not share it, and the report does not
+	// depend on the scheduling of the runs.
+	var want *SweepReport
+	for _, workers := range []int{4, 1} {
+		got, err := SweepK(context.Background(), data, []int{2, 3, 4, 5, 6},
+			WithRandSource(rand.NewSource(7)), WithWorkers(workers))
+		if err != nil {
+			t.Fatalf("SweepK() error = %v", err)
+		}
+		if want == nil {
+			want = got
+			continue
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("SweepK() with %d workers got = %+v, want %+v", workers, got, want)
+		}
+	}
+}
+
+func Test_elbow(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []SweepResult
+		want    int
+	}{
+		{
+			name:    "Test 1 - sharp elbow",
+			results: []SweepResult{{K: 1, SSE: 100}, {K: 2, SSE: 20}, {K: 3, SSE: 10}, {K: 4, SSE: 5}},
+			want:    2,
+		},
+		{
+			name:    "Test 2 - uneven k",
+			results: []SweepResult{{K: 2, SSE: 100}, {K: 4, SSE: 60}, {K: 8, SSE: 15}, {K: 16, SSE: 10}},
+			want:    8,
+		},
+		{
+			name:    "Test 3 - two values",
+			results: []SweepResult{{K: 2, SSE: 100}, {K: 4, SSE: 60}},
+			want:    2,
+		},
+		{
+			name:    "Test 4 - straight line",
+			results: []SweepResult{{K: 1, SSE: 30}, {K: 2, SSE: 20}, {K: 3, SSE: 10}},
+			want:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := elbow(tt.results); got != tt.want {
+				t.Errorf("elbow() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
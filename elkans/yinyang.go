@@ -0,0 +1,314 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"context"
+	"github.com/arjunsk/kmeans"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"sync/atomic"
+)
+
+// yinyangGroupingIterations is the number of Lloyd iterations used to group the initial centroids.
+const yinyangGroupingIterations = 5
+
+// YinyangClusterer is the Yinyang kmeans algorithm. The centroids are grouped once, by clustering the initial
+// centroids, and every vector keeps an upper bound to its centroid and one lower bound per group: the
+// distance to the closest centroid of the group, other than its own.
+//   - the global filter skips a vector when its upper bound is below all its group lower bounds.
+//   - the group filter skips the groups whose lower bound is above the best distance found so far.
+//
+// With a single group it works like HamerlyClusterer, and with one group per centroid like ElkanClusterer.
+// The bounds take n*groups memory, and their update O(n*groups) time per iteration.
+//
+// Ref Paper: https://proceedings.mlr.press/v37/ding15.pdf
+type YinyangClusterer struct {
+	clusterer
+
+	groupCnt int
+	groupOf  []int   // groupOf[c] is the group of centroid c.
+	groups   [][]int // groups[g] lists the centroids of group g.
+
+	// for each of the n vectors, we keep track of the following data
+	upper []float64 // u(x): at-most distance of x to its assigned centroid.
+	lower []float64 // lower[x*groupCnt+g]: at-least distance of x to the centroids of group g, but its own.
+}
+
+var _ kmeans.Clusterer = new(YinyangClusterer)
+
+// NewYinyangClusterer returns a YinyangClusterer for clustering vectors into clusterCnt clusters.
+// The number of groups is set with WithYinyangGroups.
+// The defaults can be overridden using the With* options.
+func NewYinyangClusterer(vectors [][]float64, clusterCnt int, opts ...Option) (*YinyangClusterer, error) {
+	cfg := newOptions(opts)
+	base, err := newClusterer(vectors, clusterCnt, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	groupCnt := cfg.yinyangGroups
+	if groupCnt == 0 {
+		groupCnt = clusterCnt / 10
+		if groupCnt < 1 {
+			groupCnt = 1
+		}
+	}
+
+	km := &YinyangClusterer{
+		clusterer: base,
+		groupCnt:  groupCnt,
+		groupOf:   make([]int, clusterCnt),
+		upper:     make([]float64, len(vectors)),
+		lower:     make([]float64, len(vectors)*groupCnt),
+	}
+	km.algo = km
+	return km, nil
+}
+
+func (km *YinyangClusterer) base() *clusterer {
+	return &km.clusterer
+}
+
+// newRun returns a single-run copy of km, seeded with seed and with its own bounds and centroids.
+func (km *YinyangClusterer) newRun(seed int64) algorithm {
+	run := &YinyangClusterer{
+		clusterer: km.clusterer.newRun(seed),
+		groupCnt:  km.groupCnt,
+		groupOf:   make([]int, km.clusterCnt),
+		upper:     make([]float64, km.vectorCnt),
+		lower:     make([]float64, km.vectorCnt*km.groupCnt),
+	}
+	run.algo = run
+	return run
+}
+
+// iterate runs Yinyang kmeans from the initial centroids.
+func (km *YinyangClusterer) iterate(ctx context.Context) error {
+	km.groupCentroids()
+
+	if err := km.initBounds(ctx); err != nil {
+		return err
+	}
+	if km.skipIterations() {
+		return nil
+	}
+
+	for iter := 0; ; iter++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		iterStart := km.startIteration()
+
+		changes, err := km.assignData(ctx)
+		if err != nil {
+			return err
+		}
+
+		newCentroids := km.recalculateCentroids()
+
+		maxShift := km.updateBounds(newCentroids)
+
+		km.centroids = newCentroids
+		km.iterations = iter + 1
+		km.recordIteration(changes, maxShift, iterStart)
+
+		if km.isConverged(iter, changes, maxShift) {
+			break
+		}
+	}
+	return nil
+}
+
+// groupCentroids groups the initial centroids with a few Lloyd iterations, seeded with the first groupCnt
+// centroids. A group may end up empty, in which case it is never searched.
+func (km *YinyangClusterer) groupCentroids() {
+	seeds := make([]*mat.VecDense, km.groupCnt)
+	for g := range seeds {
+		seeds[g] = mat.VecDenseCopyOf(km.centroids[g])
+	}
+
+	membersCount := make([]int, km.groupCnt)
+	for iter := 0; iter < yinyangGroupingIterations; iter++ {
+		for c := range km.centroids {
+			minDist := math.MaxFloat64
+			for g := range seeds {
+				if dist := km.distFn(km.centroids[c], seeds[g]); dist < minDist {
+					minDist = dist
+					km.groupOf[c] = g
+				}
+			}
+		}
+
+		sums := make([]*mat.VecDense, km.groupCnt)
+		for g := range sums {
+			sums[g] = mat.NewVecDense(km.centroids[0].Len(), nil)
+			membersCount[g] = 0
+		}
+		for c, g := range km.groupOf {
+			sums[g].AddVec(sums[g], km.centroids[c])
+			membersCount[g]++
+		}
+		for g := range seeds {
+			if membersCount[g] > 0 {
+				seeds[g].ScaleVec(1/float64(membersCount[g]), sums[g])
+			}
+		}
+	}
+
+	km.groups = make([][]int, km.groupCnt)
+	for c, g := range km.groupOf {
+		km.groups[g] = append(km.groups[g], c)
+	}
+}
+
+// initBounds assigns each vector to its closest centroid, and initializes its upper bound to the distance
+// to that centroid and its group lower bounds to the distances to the closest other centroid of each group.
+func (km *YinyangClusterer) initBounds(ctx context.Context) error {
+	parallelFor(km.vectorCnt, km.workers, func(start, end int) {
+		distances := make([]float64, km.clusterCnt)
+		for x := start; x < end; x++ {
+			if (x-start)%ctxCheckInterval == 0 && ctx.Err() != nil {
+				return
+			}
+
+			minDist := math.MaxFloat64
+			closestCenter := 0
+			for c := range km.centroids {
+				distances[c] = km.distFn(km.vectorList[x], km.centroids[c])
+				if distances[c] < minDist {
+					minDist = distances[c]
+					closestCenter = c
+				}
+			}
+			km.upper[x] = minDist
+			km.assignments[x] = closestCenter
+
+			lower := km.lower[x*km.groupCnt : (x+1)*km.groupCnt]
+			for g := range lower {
+				lower[g] = math.MaxFloat64
+			}
+			for c, dist := range distances {
+				if c != closestCenter {
+					lower[km.groupOf[c]] = math.Min(lower[km.groupOf[c]], dist)
+				}
+			}
+		}
+	})
+	return ctx.Err()
+}
+
+// assignData assigns each vector to the nearest centroid and returns the number of vectors that changed their cluster.
+func (km *YinyangClusterer) assignData(ctx context.Context) (int, error) {
+	var changes int64
+
+	parallelFor(km.vectorCnt, km.workers, func(start, end int) {
+		var chunkChanges int64
+		for x := start; x < end; x++ {
+			if (x-start)%ctxCheckInterval == 0 && ctx.Err() != nil {
+				return
+			}
+			if km.assignVector(x) {
+				chunkChanges++
+			}
+		}
+		atomic.AddInt64(&changes, chunkChanges)
+	})
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return int(changes), nil
+}
+
+// assignVector assigns a single vector and returns true if the vector changed its cluster.
+func (km *YinyangClusterer) assignVector(x int) bool {
+	lower := km.lower[x*km.groupCnt : (x+1)*km.groupCnt]
+	globalLower := math.MaxFloat64
+	for _, l := range lower {
+		globalLower = math.Min(globalLower, l)
+	}
+
+	// global filter: if u(x) <= min_g l(x, g), no other centroid can be closer than c(x).
+	if km.upper[x] <= globalLower {
+		return false
+	}
+	cx := km.assignments[x]
+	km.upper[x] = km.distFn(km.vectorList[x], km.centroids[cx])
+	if km.upper[x] <= globalLower {
+		return false
+	}
+
+	// group filter: only the groups whose lower bound is below the best distance so far can hold a closer
+	// centroid. Like in ElkanClusterer, another centroid only replaces c(x) if it is strictly closer, and
+	// ties between the other centroids go to the lowest index, whatever the order of the groups. So a group
+	// whose lower bound equals the best distance is only skipped when the best is c(x).
+	best, bestDist, bestGroup := cx, km.upper[x], km.groupOf[cx]
+	for g, members := range km.groups {
+		if lower[g] > bestDist || (lower[g] == bestDist && best == cx) {
+			continue
+		}
+
+		min1, min2, min1Idx := math.MaxFloat64, math.MaxFloat64, -1
+		for _, c := range members {
+			dist := km.upper[x]
+			if c != cx {
+				dist = km.distFn(km.vectorList[x], km.centroids[c])
+			}
+			if dist < min1 {
+				min1, min2, min1Idx = dist, min1, c
+			} else if dist < min2 {
+				min2 = dist
+			}
+		}
+
+		if min1 < bestDist || (min1 == bestDist && min1Idx < best && best != cx) {
+			// the previous best is not the closest anymore, so it bounds the distances of its group.
+			lower[bestGroup] = math.Min(lower[bestGroup], bestDist)
+			best, bestDist, bestGroup = min1Idx, min1, g
+		}
+		if min1Idx == best {
+			lower[g] = min2
+		} else {
+			lower[g] = min1
+		}
+	}
+
+	km.assignments[x] = best
+	km.upper[x] = bestDist
+	return best != cx
+}
+
+// updateBounds updates the upper and group lower bounds for each vector and returns the largest centroid shift.
+func (km *YinyangClusterer) updateBounds(newCentroids []*mat.VecDense) (maxShift float64) {
+	centroidShiftDist, maxShift := km.centroidShifts(newCentroids)
+
+	// the lower bound of a group decreases by the largest shift of its centroids.
+	groupShift := make([]float64, km.groupCnt)
+	for c, g := range km.groupOf {
+		groupShift[g] = math.Max(groupShift[g], centroidShiftDist[c])
+	}
+
+	parallelFor(km.vectorCnt, km.workers, func(start, end int) {
+		for x := start; x < end; x++ {
+			km.upper[x] += centroidShiftDist[km.assignments[x]]
+			lower := km.lower[x*km.groupCnt : (x+1)*km.groupCnt]
+			for g := range lower {
+				lower[g] = math.Max(lower[g]-groupShift[g], 0)
+			}
+		}
+	})
+	return maxShift
+}
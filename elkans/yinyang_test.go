@@ -0,0 +1,132 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elkans
+
+import (
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"reflect"
+	"testing"
+)
+
+func TestYinyangClusterer_Cluster(t *testing.T) {
+	vectorList := [][]float64{
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{1, 2, 3, 4},
+		{1, 2, 4, 5},
+		{1, 2, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+		{10, 2, 4, 5},
+		{10, 3, 4, 5},
+		{10, 5, 4, 5},
+	}
+	km, err := NewYinyangClusterer(vectorList, 2, WithInit(kmeans.Random))
+	if err != nil {
+		t.Fatalf("Error while creating KMeans object %v", err)
+	}
+	got, err := km.Cluster()
+	if err != nil {
+		t.Fatalf("Cluster() error = %v", err)
+	}
+	want := [][]float64{
+		{1, 2, 3.6666666666666665, 4.666666666666666},
+		{10, 3.333333333333333, 4, 5},
+	}
+	if !assertx.InEpsilonF64Slices(want, got) {
+		t.Errorf("Cluster() got = %v, want %v", got, want)
+	}
+	if !assertx.InEpsilonF64(12, km.SSE()) {
+		t.Errorf("SSE() got = %v, want %v", km.SSE(), 12)
+	}
+}
+
+func TestYinyangClusterer_SameAsElkan(t *testing.T) {
+	data := make([][]float64, 500)
+	populateRandData(500, 8, data)
+
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{
+			name: "Test 1 - Default groups",
+			opts: []Option{WithSeed(3)},
+		},
+		{
+			name: "Test 2 - Single group",
+			opts: []Option{WithSeed(3), WithYinyangGroups(1)},
+		},
+		{
+			name: "Test 3 - One group per centroid",
+			opts: []Option{WithSeed(3), WithYinyangGroups(16)},
+		},
+		{
+			name: "Test 4 - Spherical",
+			opts: []Option{WithSeed(5), WithYinyangGroups(4), WithDistance(kmeans.InnerProduct), WithNormalize(true)},
+		},
+		{
+			name: "Test 5 - Workers and empty clusters",
+			opts: []Option{WithSeed(7), WithYinyangGroups(4), WithWorkers(3), WithInit(kmeans.Random),
+				WithEmptyClusterPolicy(kmeans.EmptyClusterSplitLargest)},
+		},
+		{
+			name: "Test 6 - Restarts",
+			opts: []Option{WithYinyangGroups(4), WithRestarts(3), WithConcurrentRestarts(true)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ekm, err := NewElkanClusterer(data, 16, tt.opts...)
+			if err != nil {
+				t.Fatalf("Error while creating KMeans object %v", err)
+			}
+			if _, err = ekm.Cluster(); err != nil {
+				t.Fatalf("Cluster() error = %v", err)
+			}
+			ykm, err := NewYinyangClusterer(data, 16, tt.opts...)
+			if err != nil {
+				t.Fatalf("Error while creating KMeans object %v", err)
+			}
+			if _, err = ykm.Cluster(); err != nil {
+				t.Fatalf("Cluster() error = %v", err)
+			}
+
+			want, got := ekm.Result(), ykm.Result()
+			if !reflect.DeepEqual(want.Labels, got.Labels) {
+				t.Errorf("Labels differ from ElkanClusterer")
+			}
+			if !assertx.InEpsilonF64Slices(want.Centroids, got.Centroids) {
+				t.Errorf("Centroids differ from ElkanClusterer")
+			}
+			if want.Iterations != got.Iterations || want.ConvergenceReason != got.ConvergenceReason {
+				t.Errorf("Iterations got = %v (%v), want %v (%v)",
+					got.Iterations, got.ConvergenceReason, want.Iterations, want.ConvergenceReason)
+			}
+		})
+	}
+}
+
+func TestNewYinyangClusterer(t *testing.T) {
+	vectorList := [][]float64{{1}, {3}, {10}, {12}}
+	for _, groups := range []int{-1, 3} {
+		if _, err := NewYinyangClusterer(vectorList, 2, WithYinyangGroups(groups)); err == nil {
+			t.Errorf("groups=%v: NewYinyangClusterer() should fail", groups)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/moerr"
+	"gonum.org/v1/gonum/mat"
+	"math"
+)
+
+// CalinskiHarabasz returns the Calinski-Harabasz index (variance ratio criterion) of the clustering, >= 0,
+// higher is better. It is the ratio of the between-cluster dispersion, the sum of n(c) * d(c, mean)^2 over
+// the clusters, to the within-cluster dispersion, the sum of d(x, c(x))^2 over the vectors, each divided by
+// its degrees of freedom (k - 1 and n - k). If all the vectors lie on their centroid, it returns 1.
+// k is the number of non-empty clusters, which must be at least 2 and less than the number of vectors.
+// Complexity: O(n + k) distance computations.
+//
+// Ref Paper: https://doi.org/10.1080/03610927408827101
+func CalinskiHarabasz(vectors []*mat.VecDense, labels []int, centroids []*mat.VecDense,
+	distFn kmeans.DistanceFunction) (float64, error) {
+	sizes, nonEmpty, err := validate(vectors, labels, centroids)
+	if err != nil {
+		return 0, err
+	}
+	if nonEmpty < 2 || nonEmpty >= len(vectors) {
+		return 0, moerr.NewInternalErrorNoCtx("calinski-harabasz needs between 2 and %d non-empty clusters",
+			len(vectors)-1)
+	}
+
+	mean := mat.NewVecDense(vectors[0].Len(), nil)
+	for _, v := range vectors {
+		mean.AddVec(mean, v)
+	}
+	mean.ScaleVec(1/float64(len(vectors)), mean)
+
+	var between, within float64
+	for c, size := range sizes {
+		if size > 0 {
+			between += float64(size) * math.Pow(distFn(centroids[c], mean), 2)
+		}
+	}
+	for x, c := range labels {
+		within += math.Pow(distFn(vectors[x], centroids[c]), 2)
+	}
+	if within == 0 {
+		return 1, nil
+	}
+	return (between / float64(nonEmpty-1)) / (within / float64(len(vectors)-nonEmpty)), nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"gonum.org/v1/gonum/mat"
+	"testing"
+)
+
+func TestCalinskiHarabasz(t *testing.T) {
+	tests := []struct {
+		name      string
+		vectors   []*mat.VecDense
+		labels    []int
+		centroids []*mat.VecDense
+		want      float64
+		wantErr   bool
+	}{
+		{
+			// between = 2*5^2 + 2*5^2 = 100, within = 4*0.5^2 = 1.
+			name:      "Test 1 - two clusters",
+			vectors:   toVecs([]float64{0}, []float64{1}, []float64{10}, []float64{11}),
+			labels:    []int{0, 0, 1, 1},
+			centroids: toVecs([]float64{0.5}, []float64{10.5}),
+			want:      (100.0 / 1) / (1.0 / 2),
+		},
+		{
+			name:      "Test 2 - vectors on their centroid",
+			vectors:   toVecs([]float64{0}, []float64{0}, []float64{10}),
+			labels:    []int{0, 0, 1},
+			centroids: toVecs([]float64{0}, []float64{10}),
+			want:      1,
+		},
+		{
+			name:      "Test 3 - one vector per cluster",
+			vectors:   toVecs([]float64{0}, []float64{10}),
+			labels:    []int{0, 1},
+			centroids: toVecs([]float64{0}, []float64{10}),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CalinskiHarabasz(tt.vectors, tt.labels, tt.centroids, l2Distance)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CalinskiHarabasz() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !assertx.InEpsilonF64(tt.want, got) {
+				t.Errorf("CalinskiHarabasz() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/moerr"
+	"gonum.org/v1/gonum/mat"
+	"math"
+)
+
+// DaviesBouldin returns the Davies-Bouldin index of the clustering, >= 0, lower is better.
+// With S(c) the mean distance of the vectors of cluster c to its centroid, it is the mean over the clusters
+// of max{(S(c) + S(c')) / d(c, c') | c' != c}. Empty clusters are ignored, and two clusters with the same
+// centroid do not count. It needs at least 2 non-empty clusters.
+// Complexity: O(n + k^2) distance computations.
+//
+// Ref Paper: https://doi.org/10.1109/TPAMI.1979.4766909
+func DaviesBouldin(vectors []*mat.VecDense, labels []int, centroids []*mat.VecDense,
+	distFn kmeans.DistanceFunction) (float64, error) {
+	sizes, nonEmpty, err := validate(vectors, labels, centroids)
+	if err != nil {
+		return 0, err
+	}
+	if nonEmpty < 2 {
+		return 0, moerr.NewInternalErrorNoCtx("davies-bouldin needs at least 2 non-empty clusters")
+	}
+
+	scatter := make([]float64, len(centroids))
+	for x, c := range labels {
+		scatter[c] += distFn(vectors[x], centroids[c])
+	}
+	for c, size := range sizes {
+		if size > 0 {
+			scatter[c] /= float64(size)
+		}
+	}
+
+	var sum float64
+	for c := range centroids {
+		if sizes[c] == 0 {
+			continue
+		}
+		var maxRatio float64
+		for o := range centroids {
+			if o == c || sizes[o] == 0 {
+				continue
+			}
+			if dist := distFn(centroids[c], centroids[o]); dist > 0 {
+				maxRatio = math.Max(maxRatio, (scatter[c]+scatter[o])/dist)
+			}
+		}
+		sum += maxRatio
+	}
+	return sum / float64(nonEmpty), nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"gonum.org/v1/gonum/mat"
+	"testing"
+)
+
+func TestDaviesBouldin(t *testing.T) {
+	tests := []struct {
+		name      string
+		vectors   []*mat.VecDense
+		labels    []int
+		centroids []*mat.VecDense
+		want      float64
+		wantErr   bool
+	}{
+		{
+			name:      "Test 1 - two clusters",
+			vectors:   toVecs([]float64{0}, []float64{1}, []float64{10}, []float64{11}),
+			labels:    []int{0, 0, 1, 1},
+			centroids: toVecs([]float64{0.5}, []float64{10.5}),
+			want:      0.1,
+		},
+		{
+			// S = 0.5, 0 and 1. Cluster 0 is least separated from cluster 2, the others from each other.
+			name:      "Test 2 - three clusters",
+			vectors:   toVecs([]float64{0}, []float64{1}, []float64{4}, []float64{9}, []float64{11}),
+			labels:    []int{0, 0, 1, 2, 2},
+			centroids: toVecs([]float64{0.5}, []float64{4}, []float64{10}),
+			want:      (1.5/9.5 + 1.0/6 + 1.0/6) / 3,
+		},
+		{
+			name:      "Test 3 - single cluster",
+			vectors:   toVecs([]float64{0}, []float64{1}),
+			labels:    []int{0, 0},
+			centroids: toVecs([]float64{0.5}),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DaviesBouldin(tt.vectors, tt.labels, tt.centroids, l2Distance)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DaviesBouldin() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !assertx.InEpsilonF64(tt.want, got) {
+				t.Errorf("DaviesBouldin() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/arjunsk/kmeans"
+	"gonum.org/v1/gonum/mat"
+	"math"
+)
+
+// ClusterInertia returns, for each cluster, the sum of squared distances of its vectors to its centroid, or 0
+// for an empty cluster. The values add up to the SSE of the clustering, and show which clusters are loose.
+// Complexity: O(n) distance computations.
+func ClusterInertia(vectors []*mat.VecDense, labels []int, centroids []*mat.VecDense,
+	distFn kmeans.DistanceFunction) ([]float64, error) {
+	if _, _, err := validate(vectors, labels, centroids); err != nil {
+		return nil, err
+	}
+
+	inertia := make([]float64, len(centroids))
+	for x, c := range labels {
+		inertia[c] += math.Pow(distFn(vectors[x], centroids[c]), 2)
+	}
+	return inertia, nil
+}
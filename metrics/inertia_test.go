@@ -0,0 +1,79 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"testing"
+)
+
+func l1Distance(v1, v2 *mat.VecDense) float64 {
+	var dist float64
+	for i := 0; i < v1.Len(); i++ {
+		dist += math.Abs(v1.AtVec(i) - v2.AtVec(i))
+	}
+	return dist
+}
+
+func TestClusterInertia(t *testing.T) {
+	tests := []struct {
+		name      string
+		vectors   []*mat.VecDense
+		labels    []int
+		centroids []*mat.VecDense
+		distFn    kmeans.DistanceFunction
+		want      []float64
+		wantErr   bool
+	}{
+		{
+			name:      "Test 1 - L2 with an empty cluster",
+			vectors:   toVecs([]float64{0, 0}, []float64{2, 0}, []float64{10, 10}),
+			labels:    []int{0, 0, 2},
+			centroids: toVecs([]float64{1, 0}, []float64{5, 5}, []float64{10, 11}),
+			distFn:    l2Distance,
+			want:      []float64{2, 0, 1},
+		},
+		{
+			name:      "Test 2 - L1",
+			vectors:   toVecs([]float64{0, 0}, []float64{2, 2}, []float64{10, 10}),
+			labels:    []int{0, 0, 1},
+			centroids: toVecs([]float64{1, 1}, []float64{10, 11}),
+			distFn:    l1Distance,
+			want:      []float64{8, 1},
+		},
+		{
+			name:      "Test 3 - label out of bounds",
+			vectors:   toVecs([]float64{0, 0}),
+			labels:    []int{1},
+			centroids: toVecs([]float64{1, 0}),
+			distFn:    l2Distance,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ClusterInertia(tt.vectors, tt.labels, tt.centroids, tt.distFn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ClusterInertia() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !assertx.InEpsilonF64Slice(tt.want, got) {
+				t.Errorf("ClusterInertia() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
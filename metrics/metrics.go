@@ -0,0 +1,50 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics scores a clustering, to compare runs and choose k. The functions take the clustered
+// vectors, the label of each vector and the centroids, and compute the distances with the given
+// kmeans.DistanceFunction, so that the scores match the distance used for clustering.
+package metrics
+
+import (
+	"github.com/arjunsk/kmeans/utils/moerr"
+	"gonum.org/v1/gonum/mat"
+)
+
+// validate checks that there is a valid label per vector, and returns the number of vectors per cluster and
+// the number of non-empty clusters.
+func validate(vectors []*mat.VecDense, labels []int, centroids []*mat.VecDense) (sizes []int, nonEmpty int, err error) {
+	if len(vectors) == 0 {
+		return nil, 0, moerr.NewInternalErrorNoCtx("input vectors is empty")
+	}
+	if len(labels) != len(vectors) {
+		return nil, 0, moerr.NewInternalErrorNoCtx("labels count does not match vector count %d != %d",
+			len(labels), len(vectors))
+	}
+	if len(centroids) == 0 {
+		return nil, 0, moerr.NewInternalErrorNoCtx("centroids is empty")
+	}
+
+	sizes = make([]int, len(centroids))
+	for x, label := range labels {
+		if label < 0 || label >= len(centroids) {
+			return nil, 0, moerr.NewInternalErrorNoCtx("label %d of vector %d is out of bounds", label, x)
+		}
+		if sizes[label] == 0 {
+			nonEmpty++
+		}
+		sizes[label]++
+	}
+	return sizes, nonEmpty, nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"gonum.org/v1/gonum/mat"
+	"testing"
+)
+
+func l2Distance(v1, v2 *mat.VecDense) float64 {
+	diff := mat.NewVecDense(v1.Len(), nil)
+	diff.SubVec(v1, v2)
+	return mat.Norm(diff, 2)
+}
+
+func toVecs(arrays ...[]float64) []*mat.VecDense {
+	vecs := make([]*mat.VecDense, len(arrays))
+	for i, arr := range arrays {
+		vecs[i] = mat.NewVecDense(len(arr), arr)
+	}
+	return vecs
+}
+
+func Test_validate(t *testing.T) {
+	tests := []struct {
+		name         string
+		vectors      []*mat.VecDense
+		labels       []int
+		centroids    []*mat.VecDense
+		wantSizes    []int
+		wantNonEmpty int
+		wantErr      bool
+	}{
+		{
+			name:         "Test 1 - empty cluster",
+			vectors:      toVecs([]float64{1}, []float64{2}, []float64{3}),
+			labels:       []int{0, 2, 0},
+			centroids:    toVecs([]float64{1}, []float64{2}, []float64{3}),
+			wantSizes:    []int{2, 0, 1},
+			wantNonEmpty: 2,
+		},
+		{
+			name:      "Test 2 - no vectors",
+			centroids: toVecs([]float64{1}),
+			wantErr:   true,
+		},
+		{
+			name:      "Test 3 - labels count",
+			vectors:   toVecs([]float64{1}, []float64{2}),
+			labels:    []int{0},
+			centroids: toVecs([]float64{1}),
+			wantErr:   true,
+		},
+		{
+			name:      "Test 4 - label out of bounds",
+			vectors:   toVecs([]float64{1}, []float64{2}),
+			labels:    []int{0, 1},
+			centroids: toVecs([]float64{1}),
+			wantErr:   true,
+		},
+		{
+			name:    "Test 5 - no centroids",
+			vectors: toVecs([]float64{1}),
+			labels:  []int{0},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sizes, nonEmpty, err := validate(tt.vectors, tt.labels, tt.centroids)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(sizes) != len(tt.wantSizes) || nonEmpty != tt.wantNonEmpty {
+				t.Fatalf("validate() got = %v, %v, want %v, %v", sizes, nonEmpty, tt.wantSizes, tt.wantNonEmpty)
+			}
+			for c := range sizes {
+				if sizes[c] != tt.wantSizes[c] {
+					t.Errorf("validate() got = %v, want %v", sizes, tt.wantSizes)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/arjunsk/kmeans"
+	"github.com/arjunsk/kmeans/utils/moerr"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"math/rand"
+)
+
+// Silhouette returns the mean silhouette coefficient of the vectors, in [-1, 1], higher is better.
+// For a vector x, a(x) is its mean distance to the other vectors of its cluster and b(x) the smallest mean
+// distance to the vectors of another cluster, and s(x) = (b(x) - a(x)) / max(a(x), b(x)), or 0 if x is
+// alone in its cluster. It needs at least 2 non-empty clusters.
+// Complexity: O(n^2) distance computations.
+//
+// Ref Paper: https://doi.org/10.1016/0377-0427(87)90125-7
+func Silhouette(vectors []*mat.VecDense, labels []int, centroids []*mat.VecDense,
+	distFn kmeans.DistanceFunction) (float64, error) {
+	sizes, nonEmpty, err := validate(vectors, labels, centroids)
+	if err != nil {
+		return 0, err
+	}
+	if nonEmpty < 2 {
+		return 0, moerr.NewInternalErrorNoCtx("silhouette needs at least 2 non-empty clusters")
+	}
+
+	var sum float64
+	distSums := make([]float64, len(centroids))
+	for x := range vectors {
+		sum += silhouette(x, vectors, labels, sizes, distSums, distFn)
+	}
+	return sum / float64(len(vectors)), nil
+}
+
+// SampledSilhouette estimates Silhouette from sampleSize vectors drawn without replacement with rnd. The
+// coefficient of each drawn vector is exact, as it is computed against all the vectors, so the estimate is
+// unbiased. If sampleSize is not below the number of vectors, it returns Silhouette.
+// Complexity: O(sampleSize*n) distance computations.
+func SampledSilhouette(vectors []*mat.VecDense, labels []int, centroids []*mat.VecDense,
+	distFn kmeans.DistanceFunction, sampleSize int, rnd *rand.Rand) (float64, error) {
+	if sampleSize <= 0 {
+		return 0, moerr.NewInternalErrorNoCtx("sample size is out of bounds (must be > 0)")
+	}
+	if sampleSize >= len(vectors) {
+		return Silhouette(vectors, labels, centroids, distFn)
+	}
+	sizes, nonEmpty, err := validate(vectors, labels, centroids)
+	if err != nil {
+		return 0, err
+	}
+	if nonEmpty < 2 {
+		return 0, moerr.NewInternalErrorNoCtx("silhouette needs at least 2 non-empty clusters")
+	}
+
+	var sum float64
+	distSums := make([]float64, len(centroids))
+	for _, x := range rnd.Perm(len(vectors))[:sampleSize] {
+		sum += silhouette(x, vectors, labels, sizes, distSums, distFn)
+	}
+	return sum / float64(sampleSize), nil
+}
+
+// silhouette returns s(x). distSums is a buffer of one value per cluster, for the sums of the distances of x
+// to the other vectors of each cluster.
+func silhouette(x int, vectors []*mat.VecDense, labels []int, sizes []int, distSums []float64,
+	distFn kmeans.DistanceFunction) float64 {
+	own := labels[x]
+	if sizes[own] == 1 {
+		return 0
+	}
+
+	for c := range distSums {
+		distSums[c] = 0
+	}
+	for y := range vectors {
+		if y != x {
+			distSums[labels[y]] += distFn(vectors[x], vectors[y])
+		}
+	}
+
+	a := distSums[own] / float64(sizes[own]-1)
+	b := math.MaxFloat64
+	for c, size := range sizes {
+		if c != own && size > 0 {
+			b = math.Min(b, distSums[c]/float64(size))
+		}
+	}
+	if a == 0 && b == 0 {
+		return 0
+	}
+	return (b - a) / math.Max(a, b)
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 Matrix Origin
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/arjunsk/kmeans/utils/assertx"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSilhouette(t *testing.T) {
+	tests := []struct {
+		name      string
+		vectors   []*mat.VecDense
+		labels    []int
+		centroids []*mat.VecDense
+		want      float64
+		wantErr   bool
+	}{
+		{
+			// s = (10.5-1)/10.5 for the outer vectors, and (9.5-1)/9.5 for the inner ones.
+			name:      "Test 1 - two clusters",
+			vectors:   toVecs([]float64{0}, []float64{1}, []float64{10}, []float64{11}),
+			labels:    []int{0, 0, 1, 1},
+			centroids: toVecs([]float64{0.5}, []float64{10.5}),
+			want:      (9.5/10.5 + 8.5/9.5) / 2,
+		},
+		{
+			// the single vector of cluster 1 counts as 0, and the empty cluster is ignored.
+			name:      "Test 2 - singleton and empty clusters",
+			vectors:   toVecs([]float64{0}, []float64{2}, []float64{4}),
+			labels:    []int{0, 0, 1},
+			centroids: toVecs([]float64{1}, []float64{4}, []float64{100}),
+			want:      ((4.0-2)/4 + (2.0-2)/2 + 0) / 3,
+		},
+		{
+			name:      "Test 3 - single cluster",
+			vectors:   toVecs([]float64{0}, []float64{1}),
+			labels:    []int{0, 0},
+			centroids: toVecs([]float64{0.5}, []float64{10}),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Silhouette(tt.vectors, tt.labels, tt.centroids, l2Distance)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Silhouette() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !assertx.InEpsilonF64(tt.want, got) {
+				t.Errorf("Silhouette() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSampledSilhouette(t *testing.T) {
+	// 3 blobs of 200 vectors, each labelled by its blob.
+	random := rand.New(rand.NewSource(1))
+	vectors := make([]*mat.VecDense, 600)
+	labels := make([]int, 600)
+	for x := range vectors {
+		labels[x] = x % 3
+		vectors[x] = mat.NewVecDense(2, []float64{float64(labels[x]*10) + random.NormFloat64(), random.NormFloat64()})
+	}
+	centroids := toVecs([]float64{0, 0}, []float64{10, 0}, []float64{20, 0})
+
+	exact, err := Silhouette(vectors, labels, centroids, l2Distance)
+	if err != nil {
+		t.Fatalf("Silhouette() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		sampleSize int
+		tolerance  float64
+		wantErr    bool
+	}{
+		{name: "Test 1 - sample", sampleSize: 150, tolerance: 0.05},
+		{name: "Test 2 - sample of all the vectors", sampleSize: 600, tolerance: 0},
+		{name: "Test 3 - sample larger than the vectors", sampleSize: 1000, tolerance: 0},
+		{name: "Test 4 - empty sample", sampleSize: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SampledSilhouette(vectors, labels, centroids, l2Distance, tt.sampleSize,
+				rand.New(rand.NewSource(1)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SampledSilhouette() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && math.Abs(got-exact) > tt.tolerance {
+				t.Errorf("SampledSilhouette() got = %v, want %v +- %v", got, exact, tt.tolerance)
+			}
+		})
+	}
+}
@@ -14,9 +14,16 @@
 
 package kmeans
 
-import "gonum.org/v1/gonum/mat"
+import (
+	"gonum.org/v1/gonum/mat"
+	"time"
+)
 
-const DefaultRandSeed = 1
+const (
+	DefaultRandSeed       = 1
+	DefaultMaxIterations  = 500
+	DefaultDeltaThreshold = 0.01
+)
 
 type Clusterer interface {
 	InitCentroids() error
@@ -32,13 +39,206 @@ const (
 	CosineDistance
 )
 
+// Algorithm is the kmeans algorithm used to cluster the vectors. Elkan, Hamerly and Yinyang give the same
+// result as Lloyd's algorithm, but skip distance computations using the triangle inequality.
+type Algorithm uint16
+
+const (
+	// Elkan keeps k lower bounds per vector. It skips the most distance computations, but needs n*k memory.
+	Elkan Algorithm = iota
+	// Hamerly keeps a single lower bound per vector. It needs O(n) memory, and is faster than Elkan
+	// for low dimensional vectors.
+	Hamerly
+	// Yinyang groups the centroids and keeps one lower bound per group and vector. The number of groups
+	// trades memory and bound updates (Hamerly-like) for skipped distance computations (Elkan-like).
+	Yinyang
+	// Lloyd computes the distances of every vector to every centroid at each iteration. It needs no bounds,
+	// and is the reference the other algorithms are checked against.
+	Lloyd
+	// MiniBatch updates the centroids from small random batches of vectors instead of full passes. It is much
+	// faster for very large datasets, at the cost of a slightly higher SSE.
+	MiniBatch
+)
+
 type InitType uint16
 
 const (
 	Random InitType = iota
 	KmeansPlusPlus
+	// KmeansParallel is k-means||, the scalable variant of kmeans++ for large datasets.
+	KmeansParallel
+	// RandomPartition assigns every vector to a random cluster and uses the cluster means.
+	RandomPartition
+	// FarthestFirst starts from a random vector and repeatedly picks the vector farthest from the chosen ones.
+	FarthestFirst
+	// PCASplit repeatedly splits the cluster with the largest SSE along its principal direction.
+	PCASplit
 )
 
+// EmptyClusterPolicy decides the new centroid of a cluster that has no vectors assigned to it.
+type EmptyClusterPolicy uint16
+
+const (
+	// EmptyClusterRandomVector replaces the centroid with a vector of uniform random values in [0, 1).
+	EmptyClusterRandomVector EmptyClusterPolicy = iota
+	// EmptyClusterFarthestPoint replaces the centroid with the vector farthest from its own centroid, which is
+	// moved out of its cluster.
+	EmptyClusterFarthestPoint
+	// EmptyClusterSplitLargest splits the cluster with the most vectors into two close centroids.
+	EmptyClusterSplitLargest
+	// EmptyClusterSplitHighestSSE splits the cluster with the largest SSE into two close centroids.
+	EmptyClusterSplitHighestSSE
+	// EmptyClusterRandomPoint replaces the centroid with a vector drawn from the input vectors, which is not
+	// already a centroid.
+	EmptyClusterRandomPoint
+	// EmptyClusterKeepPrevious keeps the centroid of the previous iteration.
+	EmptyClusterKeepPrevious
+)
+
+// AutoKCriterion decides whether a cluster is split in two when the number of clusters is chosen automatically.
+type AutoKCriterion uint16
+
+const (
+	// XMeans splits a cluster when the two halves have a higher Bayesian Information Criterion than the
+	// cluster, modelling the clusters as spherical gaussians.
+	XMeans AutoKCriterion = iota
+	// GMeans splits a cluster when its vectors, projected on the line between the centroids of the two halves,
+	// fail the Anderson-Darling normality test.
+	GMeans
+)
+
+// ConvergenceType is the criterion that is compared against deltaThreshold to stop the clustering
+// before maxIterations. The clustering always stops when no vector changes its cluster.
+type ConvergenceType uint16
+
+const (
+	// ReassignmentRatio stops when the fraction of vectors that changed their cluster is below deltaThreshold.
+	ReassignmentRatio ConvergenceType = iota
+	// CentroidShift stops when the largest distance moved by a centroid is below deltaThreshold.
+	CentroidShift
+	// SSEImprovement stops when (prevSSE - SSE) / prevSSE is below deltaThreshold.
+	SSEImprovement
+)
+
+// ConvergenceReason tells why a clustering run stopped.
+type ConvergenceReason uint16
+
+const (
+	// NotConverged is reported when the run has not completed.
+	NotConverged ConvergenceReason = iota
+	// MaxIterationsReached is reported when the run stopped after maxIterations.
+	MaxIterationsReached
+	// NoReassignments is reported when no vector changed its cluster in the last iteration.
+	NoReassignments
+	// ReassignmentRatioBelowThreshold is reported when the fraction of vectors that changed their cluster
+	// in the last iteration dropped below deltaThreshold.
+	ReassignmentRatioBelowThreshold
+	// CentroidShiftBelowThreshold is reported when the largest centroid movement in the last iteration
+	// dropped below deltaThreshold.
+	CentroidShiftBelowThreshold
+	// SSEImprovementBelowThreshold is reported when the relative SSE improvement in the last iteration
+	// dropped below deltaThreshold.
+	SSEImprovementBelowThreshold
+	// Cancelled is reported when the run was stopped by its context.
+	Cancelled
+	// InertiaNotImproving is reported when the smoothed inertia of the mini-batches stopped improving.
+	InertiaNotImproving
+)
+
+func (r ConvergenceReason) String() string {
+	switch r {
+	case NotConverged:
+		return "not converged"
+	case MaxIterationsReached:
+		return "max iterations reached"
+	case NoReassignments:
+		return "no reassignments"
+	case ReassignmentRatioBelowThreshold:
+		return "reassignment ratio below threshold"
+	case CentroidShiftBelowThreshold:
+		return "centroid shift below threshold"
+	case SSEImprovementBelowThreshold:
+		return "sse improvement below threshold"
+	case Cancelled:
+		return "cancelled"
+	case InertiaNotImproving:
+		return "inertia not improving"
+	default:
+		return "unknown"
+	}
+}
+
+// ClusterResult is the outcome of a clustering run.
+type ClusterResult struct {
+	// Centroids holds the final k centroids.
+	Centroids [][]float64
+	// Labels[i] is the index of the centroid to which the i-th input vector is assigned.
+	Labels []int
+	// ClusterSizes[c] is the number of vectors assigned to centroid c.
+	ClusterSizes []int64
+	// ClusterSSE[c] is the sum of squared distances of the vectors assigned to centroid c.
+	ClusterSSE []float64
+	// SSE is the sum of squared errors over all the vectors.
+	SSE float64
+	// Iterations is the number of iterations executed.
+	Iterations int
+	// ConvergenceReason is the criterion that stopped the run.
+	ConvergenceReason ConvergenceReason
+	// EmptyClusterReseeds is the number of times the centroid of an empty cluster was replaced according to the
+	// EmptyClusterPolicy. Keeping the previous centroid is not counted.
+	EmptyClusterReseeds int
+}
+
+// RunStats are the statistics of a clustering run, collected when the clusterer is built with WithStats.
+type RunStats struct {
+	// Iterations is the number of iterations executed.
+	Iterations int
+	// DistanceComputations is the number of calls to the distance function, including the initialization.
+	DistanceComputations int64
+	// SkippedByStep2 is the number of vector-centroid distances not computed because the upper bound of the
+	// vector was below half the distance from its centroid to the closest other one: u(x) <= s(c(x)).
+	// Only collected by ElkanClusterer.
+	SkippedByStep2 int64
+	// SkippedByStep3 is the number of vector-centroid distances not computed for the vectors that passed
+	// step 2, thanks to the lower bounds and the centroid distances. Only collected by ElkanClusterer.
+	SkippedByStep3 int64
+	// PerIteration holds the statistics of each iteration. It is not collected by MiniBatchClusterer.
+	PerIteration []IterationStats
+	// PhaseDurations is the wall time spent in each phase of the run.
+	PhaseDurations PhaseDurations
+}
+
+// IterationStats are the statistics of an iteration of a clustering run.
+type IterationStats struct {
+	// Changes is the number of vectors that changed their cluster.
+	Changes int
+	// SSE is the sum of squared errors of the assignments of the iteration to the updated centroids.
+	SSE float64
+	// MaxShift is the largest distance moved by a centroid.
+	MaxShift float64
+	// DistanceComputations is the number of calls to the distance function during the iteration.
+	DistanceComputations int64
+	// Duration is the wall time of the iteration.
+	Duration time.Duration
+}
+
+// PhaseDurations is the wall time spent in each phase of a clustering run. The phases after Init are only
+// timed by ElkanClusterer, and are named after the steps of Elkan's paper.
+type PhaseDurations struct {
+	// Init is the time spent initializing the centroids (step 0.1).
+	Init time.Duration
+	// InitBounds is the time spent on the first assignment and bounds (step 0.2).
+	InitBounds time.Duration
+	// CentroidDistances is the time spent computing the distances between centroids (step 1).
+	CentroidDistances time.Duration
+	// Assignment is the time spent assigning the vectors (steps 2 and 3).
+	Assignment time.Duration
+	// Recalculation is the time spent computing the new centroids, with the empty clusters (step 4).
+	Recalculation time.Duration
+	// BoundUpdate is the time spent updating the bounds (steps 5 and 6).
+	BoundUpdate time.Duration
+}
+
 // DistanceFunction is a function that computes the distance between two vectors
 // NOTE: clusterer already ensures that the all the input vectors are of the same length,
 // so we don't need to check for that here again and return error if the lengths are different.